@@ -1,5 +1,5 @@
 /*eslint-env browser*/
-/*global m:false hljs:false Mousetrap:false metadata:false */
+/*global m:false hljs:false Mousetrap:false metadata:false extraFiles:false */
 
 import path from "path";
 import * as storage from "./lib/storage";
@@ -45,7 +45,9 @@ class AppController {
     this.currentFile = m.prop(metadata);
     this.currentDiff = m.prop(document.querySelector("#diff").innerHTML);
     this.fileSaved = false;
-    storage.addFile(metadata, this.currentDiff()).then(() => {
+    let saved = [storage.addFile(metadata, this.currentDiff())];
+    extraFiles.forEach((file) => saved.push(storage.addFile(file.metadata, file.diff)));
+    Promise.all(saved).then(() => {
       this.setCurrentFile(metadata);
       this.fileSaved = true;
     });
@@ -58,6 +60,11 @@ class AppController {
     this.showEmpty = m.prop(this.config.showEmpty);
     this.wrapLines = m.prop(this.config.wrap);
 
+    // imageMode and imageSlider are the selected view of image diffs, and
+    // the position of its slider, which are kept when switching files.
+    this.imageMode = m.prop("side");
+    this.imageSlider = m.prop(50);
+
     // polling to detect if storage changes. if it changes,
     // then close this tab because that indicates another was
     // opened. only poll for 1 second, after that consider this tab
@@ -89,7 +96,7 @@ class AppController {
     // another tab opened a diff for the same dir (within maxDelayMillis)
     // so close this tab.
     // todo: save a copy of the storage so refresh works?
-    storage.hasMore(metadata.dirhash, metadata.timestamp).then((hasMore) => {
+    storage.hasMore(metadata.dirhash, metadata.timestamp, 1 + extraFiles.length).then((hasMore) => {
       if (hasMore) window.close();
     });
   }
@@ -159,6 +166,7 @@ class AppController {
         rg.style.height = h + 'px';
       }
     }
+    drawMoves(document);
   }
 
   setCurrentFile(metadata) {
@@ -175,7 +183,8 @@ class AppController {
     let groups = {};
     let fileList = [];
     this.fileList([]);
-    if (!this.config.shouldCollapse) {
+    // files in the same page are always listed, even if collapsing is disabled
+    if (!this.config.shouldCollapse && extraFiles.length == 0) {
       return;
     }
     storage.collect(metadata.dirhash, metadata.timestamp, (meta) => {
@@ -234,11 +243,108 @@ function sidebar(dir, ctrl) {
       }
       return m(".sidebar-entry" + k, {
         onclick: () => ctrl.setCurrentFile(meta)
-      }, path.basename(meta.merged));
+      }, [
+        path.basename(meta.merged),
+        meta.similarity ? m("span.sidebar-similarity", `${meta.similarity}%`) : null
+      ]);
     }));
   });
 }
 
+// similarity describes how similar a renamed or copied file is to the
+// original file, e.g. "renamed from a.txt, 87% similar".
+function similarity(meta) {
+  if (!meta.similarity) {
+    return null;
+  }
+  return m("span.diff-header-similarity",
+    `${meta.change} from ${path.basename(meta.from)}, ${meta.similarity}% similar`);
+}
+
+// initImages sets up the image diffs rendered by formatter.ImageHTML. The
+// mode buttons switch between side by side, swipe, onion skin and
+// difference views, and the slider controls the swipe position and the
+// opacity of the onion skin.
+function initImages(el, ctrl) {
+  let views = el.querySelectorAll(".delta-image");
+  for (let i = 0; i < views.length; i++) {
+    let view = views[i];
+    let buttons = view.querySelectorAll(".delta-image-mode");
+    let slider = view.querySelector(".delta-image-slider");
+    let to = view.querySelector(".delta-image-to");
+
+    let update = () => {
+      // fall back to side by side if the mode is not available for this file
+      let mode = ctrl.imageMode();
+      if (view.querySelector(`.delta-image-mode[data-mode=${mode}]`) == null) {
+        mode = "side";
+      }
+      view.className = `delta-image delta-image-mode-${mode}`;
+      for (let j = 0; j < buttons.length; j++) {
+        buttons[j].classList.toggle("delta-image-mode-selected", buttons[j].dataset.mode == mode);
+      }
+      slider.value = ctrl.imageSlider();
+      to.style.width = mode == "swipe" ? `${ctrl.imageSlider()}%` : "";
+      to.style.opacity = mode == "onion" ? ctrl.imageSlider() / 100 : "";
+    };
+
+    for (let j = 0; j < buttons.length; j++) {
+      buttons[j].addEventListener("click", () => {
+        ctrl.imageMode(buttons[j].dataset.mode);
+        update();
+      });
+    }
+    slider.addEventListener("input", () => {
+      ctrl.imageSlider(slider.value);
+      update();
+    });
+    update();
+  }
+}
+
+// drawMoves connects the blocks of moved lines, which formatter.HTMLLines
+// marks with the classes lmv and mv-n, from their position in the left
+// pane to their position in the right pane. It is called again whenever
+// the height of the lines changes.
+function drawMoves(el) {
+  let old = el.querySelector(".move-connectors");
+  if (old != null) {
+    old.remove();
+  }
+  let right = el.querySelector("#gutter-right");
+  if (right == null || el.querySelector("#diff-left") == null) {
+    return;
+  }
+  let ns = "http://www.w3.org/2000/svg";
+  let svg = document.createElementNS(ns, "svg");
+  svg.setAttribute("class", "move-connectors");
+  right.parentNode.insertBefore(svg, right);
+  let box = svg.getBoundingClientRect();
+
+  // extent returns the top and bottom of the nth moved block in a pane
+  let extent = (pane, n) => {
+    let lines = el.querySelectorAll(`#${pane} .mv-${n}`);
+    if (lines.length == 0) {
+      return null;
+    }
+    return [
+      lines[0].getBoundingClientRect().top - box.top,
+      lines[lines.length - 1].getBoundingClientRect().bottom - box.top
+    ];
+  };
+  for (let n = 0; ; n++) {
+    let a = extent("diff-left", n);
+    let b = extent("diff-right", n);
+    if (a == null || b == null) {
+      return;
+    }
+    let p = document.createElementNS(ns, "polygon");
+    p.setAttribute("class", "connector-move");
+    p.setAttribute("points", `0,${a[0]} ${box.width},${b[0]} ${box.width},${b[1]} 0,${a[1]}`);
+    svg.appendChild(p);
+  }
+}
+
 window.App = (config) => {
   return {
     controller: () => new AppController(config),
@@ -286,7 +392,10 @@ window.App = (config) => {
           ctrl.currentFile() == null ? null : [
             m(".diff-section.diff-section-headers",
               ctrl.currentFile().merged != null ?
-                m(".diff-pane", ctrl.currentFile().merged) : [
+                m(".diff-pane", [
+                  ctrl.currentFile().merged,
+                  similarity(ctrl.currentFile())
+                ]) : [
                   m(".diff-pane", ctrl.currentFile().from),
                   m(".diff-pane", ctrl.currentFile().to)
                 ]
@@ -297,6 +406,8 @@ window.App = (config) => {
                 while (doc.childNodes.length > 0) {
                   el.appendChild(doc.childNodes[0]);
                 }
+                initImages(el, ctrl);
+                drawMoves(el);
               }
             })
           ])
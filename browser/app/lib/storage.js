@@ -5,14 +5,16 @@ const filesetExpiration = 10*60*1000; // filesets expire in 10 minutes.
 const filesetGroupingWindow = 5000;
 const pouch = new PouchDB("delta");
 
-export function hasMore(dirhash, ts) {
+// hasMore returns true if files other than the given number of files
+// added by this page have been added since ts.
+export function hasMore(dirhash, ts, count = 1) {
   let start = `dm-${dirhash}-${ts}`;
   let end = `dm-${dirhash}-${ts+filesetGroupingWindow+1}-`;
   return pouch.allDocs({
     startkey: start,
     endkey: end,
   }).then((result) => {
-    return result.rows.length > 1;
+    return result.rows.length > count;
   }).catch((err) => {
     console.log("hasMore error:");
     console.log(err);
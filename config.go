@@ -18,6 +18,18 @@ type Config struct {
 	Highlight         *bool    `json:"highlight"`
 	UnmodifiedOpacity *float32 `json:"unmodifiedOpacity"`
 	DiffFontSize      *int32   `json:"diffFontSize"`
+	Algorithm         *string  `json:"algorithm"`
+	FindRenames       *int     `json:"findRenames"`
+	FindCopies        *bool    `json:"findCopies"`
+	ColorMoved        *bool    `json:"colorMoved"`
+	Whitespace        *string  `json:"whitespace"`
+	IgnoreBlankLines  *bool    `json:"ignoreBlankLines"`
+	IgnoreCRAtEOL     *bool    `json:"ignoreCrAtEol"`
+	Weights           *string  `json:"weights"`
+	Granularity       *string  `json:"granularity"`
+	Cleanup           *float64 `json:"cleanup"`
+	IndentHeuristic   *bool    `json:"indentHeuristic"`
+	Hex               *bool    `json:"hex"`
 }
 
 func loadConfig() (config Config, err error) {
@@ -27,9 +39,14 @@ func loadConfig() (config Config, err error) {
 	}
 	deltarc := filepath.Join(usr.HomeDir, configFile)
 	f, err := os.Open(deltarc)
-	if (err != nil && os.IsExist(err)) || f == nil {
+	if os.IsNotExist(err) {
+		// the config file is optional
+		return config, nil
+	}
+	if err != nil {
 		return
 	}
+	defer f.Close()
 	d, err := ioutil.ReadAll(f)
 	if err != nil {
 		return
@@ -9,10 +9,20 @@ package main
 		file1 is set to the name of the temporary file containing the contents of the diff pre-image.
 		file2 is set to the name of the temporary file containing the contents of the diff post-image.
 		merged is the name of the file which is being compared.
+
+		`delta --merge <base> <local> <remote> <merged>`
+
+		Merges local and remote into merged, writing conflict markers for
+		overlapping changes. The exit code is 1 if there are conflicts.
+
+		`git diff | delta`
+
+		If no files are given, a patch is read from stdin.
 */
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -36,7 +46,10 @@ const (
 
 	FormatOptionHTML    = "html"
 	FormatOptionText    = "text"
+	FormatOptionUnified = "unified"
 	FormatOptionDefault = "default"
+
+	AlgorithmOptionDefault = "histogram"
 )
 
 var (
@@ -44,10 +57,38 @@ var (
 	install   = flag.Bool("install", false, "Install to gitconfig.")
 	uninstall = flag.Bool("uninstall", false, "Remove from gitconfig.")
 	version   = flag.Bool("version", false, "Display delta version.")
+	merge     = flag.Bool("merge", false, "Merge BASE LOCAL REMOTE into MERGED.")
 
 	// diff settings
-	output = flag.String("output", "cli", "Where to send the output. Valid values: browser (default), cli, gist.")
-	format = flag.String("format", "default", `Format of the output. `)
+	output  = flag.String("output", "cli", "Where to send the output. Valid values: browser (default), cli, gist.")
+	format  = flag.String("format", "default", `Format of the output. `)
+	unified = flag.Int("unified", formatter.DefaultContext, "Number of lines of context in unified output.")
+
+	algorithm       = flag.String("algorithm", "", "Diff algorithm. Valid values: histogram (default), myers, patience, sequence.")
+	colorMoved      = flag.Bool("color-moved", false, "Highlight blocks of lines which were moved.")
+	indentHeuristic = flag.Bool("indent-heuristic", false, "Shift changes to fit the indentation around them, like git's --indent-heuristic.")
+	weights         = flag.String("weights", "", "Scoring weights, e.g. match=100,deletion=-2,mismatch=-1,new-mode=0,gap-open=-10.")
+	timeout         = flag.Duration("timeout", 0, "Show a coarse diff if diffing takes longer than the given duration, e.g. 10s.")
+
+	granularity = flag.String("granularity", "", "Granularity of intra-line diffs. Valid values: word (default), char, grapheme.")
+	cleanup     = flag.Float64("cleanup", delta.DefaultCleanup, "Aggressiveness of the semantic cleanup of intra-line diffs, or 0 to disable.")
+
+	// whitespace settings
+	whitespace        = flag.String("whitespace", "", "How whitespace is compared. Valid values: exact, ignore-surrounding-space (default), ignore-trailing-space, ignore-space-change, ignore-all-space.")
+	ignoreAllSpace    = flag.Bool("w", false, "Ignore all whitespace.")
+	ignoreSpaceChange = flag.Bool("b", false, "Ignore changes in the amount of whitespace.")
+	ignoreBlankLines  = flag.Bool("ignore-blank-lines", false, "Ignore changes which only add or delete blank lines.")
+	ignoreCRAtEOL     = flag.Bool("ignore-cr-at-eol", false, "Ignore carriage returns at the end of lines.")
+
+	// binary settings
+	hexDump = flag.Bool("hex", false, "Diff binary files as hex dumps.")
+
+	// rename settings
+	findRenames = flag.Int("find-renames", delta.DefaultRenameThreshold, "Minimum similarity (in percent) of renamed files, or 0 to disable.")
+	findCopies  = flag.Bool("find-copies", false, "Also detect copied files.")
+
+	// merge settings
+	conflictStyle = flag.String("conflict-style", "merge", "Style of conflict markers. Valid values: merge (default), diff3.")
 )
 
 func main() {
@@ -64,12 +105,27 @@ func main() {
 		}
 		return
 	}
+	if *merge {
+		if flag.NArg() != 4 {
+			printHelp()
+			os.Exit(mergeExitError)
+		}
+		os.Exit(runMerge(flag.Arg(0), flag.Arg(1), flag.Arg(2), flag.Arg(3)))
+	}
+	if flag.NArg() == 0 && stdinIsPipe() {
+		runPager()
+		return
+	}
 	if flag.NArg() < 2 {
 		printVersion()
 		printHelp()
 		return
 	}
 	pathFrom, pathTo := flag.Arg(0), flag.Arg(1)
+	if isDir(pathFrom) && isDir(pathTo) {
+		runDirDiff(pathFrom, pathTo)
+		return
+	}
 	pathBase := pathTo
 	if flag.NArg() > 2 {
 		pathBase = flag.Arg(2)
@@ -89,8 +145,37 @@ func printHelp() {
 
 	// diff settings
 	fmt.Println("\ndelta [OPTIONS] FILE1 FILE2")
+	fmt.Println("delta [OPTIONS] DIR1 DIR2")
+	fmt.Printf("%-20s %s\n", "  --output", "Where to send the output. Valid values: browser, cli (default), gist.")
+	fmt.Printf("%-20s %s\n", "  --format", `Valid values: default (text for cli, html otherwise), html, text, unified.`)
+	fmt.Printf("%-20s %s\n", "  --unified", "Number of lines of context in unified output (default 3).")
+	fmt.Printf("%-20s %s\n", "  --algorithm", "Valid values: "+strings.Join(delta.Algorithms(), ", ")+". Default: histogram.")
+	fmt.Printf("%-20s %s\n", "  --color-moved", "Highlight blocks of lines which were moved.")
+	fmt.Printf("%-20s %s\n", "  --indent-heuristic", "Shift changes to fit the indentation around them, like git's --indent-heuristic.")
+	fmt.Printf("%-20s %s\n", "  --granularity", "Granularity of intra-line diffs. Valid values: word (default), char, grapheme.")
+	fmt.Printf("%-20s %s\n", "  --cleanup", "Merge short unchanged text between intra-line changes: higher values merge more, 0 disables (default 1).")
+	fmt.Printf("%-20s %s\n", "  --timeout", "Show a coarse diff if diffing a file, or the changes within its lines, takes longer than the given duration, e.g. 10s.")
+	fmt.Printf("%-20s %s\n", "  --weights", "Scoring weights as name=value pairs, e.g. gap-open=-10. Valid names: deletion, match, mismatch, new-mode, gap-open, gap-extend. Used by the "+strings.Join(weightedAlgorithms(), ", ")+" algorithms.")
+	fmt.Printf("%-20s %s\n", "  --whitespace", "Valid values: exact, ignore-surrounding-space (default), ignore-trailing-space, ignore-space-change, ignore-all-space.")
+	fmt.Printf("%-20s %s\n", "  -w", "Ignore all whitespace.")
+	fmt.Printf("%-20s %s\n", "  -b", "Ignore changes in the amount of whitespace.")
+	fmt.Printf("%-20s %s\n", "  --ignore-blank-lines", "Ignore changes which only add or delete blank lines.")
+	fmt.Printf("%-20s %s\n", "  --ignore-cr-at-eol", "Ignore carriage returns at the end of lines, e.g. to compare CRLF and LF files.")
+	fmt.Printf("%-20s %s\n", "  --hex", "Diff binary files as hex dumps, rather than only showing their sizes and hashes.")
+	fmt.Printf("%-20s %s\n", "  --find-renames", "Minimum similarity (in percent) of renamed files, or 0 to disable (default 50).")
+	fmt.Printf("%-20s %s\n", "  --find-copies", "Also detect copied files in directory diffs.")
+
+	// merge settings
+	fmt.Println("\ndelta --merge [OPTIONS] BASE LOCAL REMOTE MERGED")
+	fmt.Printf("%-20s %s\n", "  --conflict-style", "Valid values: merge (default), diff3.")
+
+	// pager settings
+	fmt.Println("\ngit diff | delta [OPTIONS]")
 	fmt.Printf("%-20s %s\n", "  --output", "Where to send the output. Valid values: browser, cli (default), gist.")
 	fmt.Printf("%-20s %s\n", "  --format", `Valid values: default (text for cli, html otherwise), html, text.`)
+	fmt.Printf("%-20s %s\n", "  --find-renames", "Minimum similarity (in percent) of renamed files in html output, or 0 to disable (default 50).")
+	fmt.Printf("%-20s %s\n", "  --granularity", "Granularity of intra-line diffs. Valid values: word (default), char, grapheme.")
+	fmt.Printf("%-20s %s\n", "  --cleanup", "Merge short unchanged text between intra-line changes: higher values merge more, 0 disables (default 1).")
 	fmt.Println()
 }
 
@@ -98,19 +183,65 @@ func printVersion() {
 	fmt.Println("delta", Version)
 }
 
-func runDiff(pathFrom, pathTo, pathBase string) {
+// loadSettings loads the config file, and fills in options which were not
+// set on the command line from the config or their defaults.
+func loadSettings() Config {
 	config, err := loadConfig()
 	if err != nil {
 		os.Stderr.WriteString("warning: error parsing .deltarc file")
 	}
-	d, err := diff(pathFrom, pathTo)
-	if err != nil {
-		os.Stderr.WriteString(err.Error())
-		return
+	if *algorithm == "" {
+		*algorithm = AlgorithmOptionDefault
+		if config.Algorithm != nil {
+			*algorithm = *config.Algorithm
+		}
 	}
-	if err != nil {
-		os.Stderr.WriteString(err.Error())
-		return
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["find-renames"] && config.FindRenames != nil {
+		*findRenames = *config.FindRenames
+	}
+	if !set["find-copies"] && config.FindCopies != nil {
+		*findCopies = *config.FindCopies
+	}
+	if !set["color-moved"] && config.ColorMoved != nil {
+		*colorMoved = *config.ColorMoved
+	}
+	if !set["indent-heuristic"] && config.IndentHeuristic != nil {
+		*indentHeuristic = *config.IndentHeuristic
+	}
+	if !set["cleanup"] && config.Cleanup != nil {
+		*cleanup = *config.Cleanup
+	}
+	if !set["weights"] && config.Weights != nil {
+		*weights = *config.Weights
+	}
+	if *granularity == "" {
+		*granularity = string(delta.GranularityWord)
+		if config.Granularity != nil {
+			*granularity = *config.Granularity
+		}
+	}
+	if *whitespace == "" {
+		*whitespace = string(delta.WhitespaceIgnoreSurrounding)
+		if config.Whitespace != nil {
+			*whitespace = *config.Whitespace
+		}
+	}
+	if *ignoreSpaceChange {
+		*whitespace = string(delta.WhitespaceIgnoreChange)
+	}
+	if *ignoreAllSpace {
+		*whitespace = string(delta.WhitespaceIgnoreAll)
+	}
+	if !set["ignore-blank-lines"] && config.IgnoreBlankLines != nil {
+		*ignoreBlankLines = *config.IgnoreBlankLines
+	}
+	if !set["ignore-cr-at-eol"] && config.IgnoreCRAtEOL != nil {
+		*ignoreCRAtEOL = *config.IgnoreCRAtEOL
+	}
+	if !set["hex"] && config.Hex != nil {
+		*hexDump = *config.Hex
 	}
 	if *format == FormatOptionDefault {
 		switch *output {
@@ -120,10 +251,21 @@ func runDiff(pathFrom, pathTo, pathBase string) {
 			*format = FormatOptionText
 		}
 	}
+	return config
+}
+
+func runDiff(pathFrom, pathTo, pathBase string) {
+	config := loadSettings()
+	fd, err := diff(pathFrom, pathTo, *algorithm)
+	if err != nil {
+		os.Stderr.WriteString(err.Error())
+		return
+	}
+	displayFrom, displayTo := displayPaths(pathFrom, pathTo)
 
 	switch *format {
 	case FormatOptionHTML:
-		page, err := html(d, pathFrom, pathTo, pathBase, config)
+		page, err := html(fd, pathFrom, pathTo, pathBase, config)
 		if err != nil {
 			os.Stderr.WriteString(err.Error())
 			return
@@ -140,18 +282,41 @@ func runDiff(pathFrom, pathTo, pathBase string) {
 	case FormatOptionText:
 		switch *output {
 		case OutputOptionCLI:
-			fmt.Println(formatter.ColoredText(d))
+			fmt.Println(fd.coloredText(displayFrom, displayTo))
+		case OutputOptionGist:
+			uploadGist([]byte(fd.text(displayFrom, displayTo)))
+		case OutputOptionBrowser:
+			browser.OpenReader(bytes.NewBufferString(fd.text(displayFrom, displayTo)))
+		}
+
+	case FormatOptionUnified:
+		patch := fd.unified(displayFrom, displayTo)
+		switch *output {
+		case OutputOptionCLI:
+			fmt.Print(patch)
 		case OutputOptionGist:
-			uploadGist([]byte(formatter.Text(d)))
+			uploadGist([]byte(patch))
 		case OutputOptionBrowser:
-			browser.OpenReader(bytes.NewBufferString(formatter.Text(d)))
+			browser.OpenReader(bytes.NewBufferString(patch))
 		}
 	}
 }
 
-// openDiffs diffs the given files and writes the result to a tempfile,
-// then opens it in the gui.
-func html(d *delta.DiffSolution, pathFrom, pathTo, pathBase string, config Config) (*bytes.Buffer, error) {
+// displayPaths normalizes paths so we don't have tmp on the path. When used
+// as a git difftool, one of the paths is usually a temporary file.
+func displayPaths(pathFrom, pathTo string) (string, string) {
+	tmpFrom := strings.HasPrefix(pathFrom, os.TempDir())
+	tmpTo := strings.HasPrefix(pathTo, os.TempDir())
+	if tmpFrom && !tmpTo {
+		return pathTo, pathTo
+	} else if !tmpFrom && tmpTo {
+		return pathFrom, pathFrom
+	}
+	return pathFrom, pathTo
+}
+
+// html renders the diff of a single file as a html page.
+func html(fd *fileDiff, pathFrom, pathTo, pathBase string, config Config) (*bytes.Buffer, error) {
 	change := changeModified
 	if pathTo == "/dev/null" {
 		change = changeDeleted
@@ -159,36 +324,78 @@ func html(d *delta.DiffSolution, pathFrom, pathTo, pathBase string, config Confi
 		change = changeAdded
 	}
 
-	// normalize paths so we don't have tmp on the path
-	tmpFrom := strings.HasPrefix(pathFrom, os.TempDir())
-	tmpTo := strings.HasPrefix(pathTo, os.TempDir())
-	if tmpFrom && !tmpTo {
-		pathFrom = pathTo
-	} else if !tmpFrom && tmpTo {
-		pathTo = pathFrom
+	pathFrom, pathTo = displayPaths(pathFrom, pathTo)
+	return page([]*File{newFile(pathFrom, pathTo, pathBase, change, fd.html())}, config)
+}
+
+// formatterOptions returns the options of the html and colored text
+// formatters. The granularity must have been validated, e.g. by diff.
+func formatterOptions() []formatter.Option {
+	return []formatter.Option{
+		formatter.WithGranularity(delta.Granularity(*granularity)),
+		formatter.WithCleanup(*cleanup),
+	}
+}
+
+// timeoutContext returns a context which is done after --timeout, or which
+// is never done if it is not set. It limits the time taken to diff a file,
+// and separately the time taken to highlight the changes within its lines.
+func timeoutContext() (context.Context, context.CancelFunc) {
+	if *timeout > 0 {
+		return context.WithTimeout(context.Background(), *timeout)
 	}
+	return context.WithCancel(context.Background())
+}
+
+// weightedAlgorithms returns the names of the algorithms which use
+// --weights.
+func weightedAlgorithms() []string {
+	names := []string{}
+	for _, name := range delta.Algorithms() {
+		if delta.AlgorithmWeighted(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// newFile returns a File for the given html diff, for use with page.
+func newFile(pathFrom, pathTo, pathBase string, change change, html string) *File {
+	return &File{
+		Metadata: &Metadata{
+			From:   pathFrom,
+			To:     pathTo,
+			Merged: pathBase,
+			Change: change,
+			Hash:   md5sum(pathBase + html),
+		},
+		Diff: html,
+	}
+}
 
+// page renders the given files into a html page for the delta GUI. The
+// first file is displayed initially, and the others are listed in the
+// sidebar.
+func page(files []*File, config Config) (*bytes.Buffer, error) {
 	wd, _ := os.Getwd()
-	html := formatter.HTML(d)
-	m := &Metadata{
-		From:      pathFrom,
-		To:        pathTo,
-		Merged:    pathBase,
-		Dir:       wd,
-		Change:    change,
-		Hash:      md5sum(html),
-		DirHash:   md5sum(wd),
-		Timestamp: time.Now().UnixNano() / 1000000, // convert to millis
-	}
-	meta, _ := json.Marshal(m)
+	timestamp := time.Now().UnixNano() / 1000000 // convert to millis
+	for _, f := range files {
+		f.Metadata.Dir = wd
+		f.Metadata.DirHash = md5sum(wd)
+		f.Metadata.Timestamp = timestamp
+	}
+
+	meta, _ := json.Marshal(files[0].Metadata)
+	extraFiles, _ := json.Marshal(files[1:])
 	cfg, _ := json.Marshal(config)
 	tmpl := template.Must(template.New("compare").Parse(getAsset("compare.html")))
 	buf := &bytes.Buffer{}
 	err := tmpl.Execute(buf, map[string]interface{}{
-		"metadata": template.JS(string(meta)),
-		"config":   template.JS(cfg),
-		"content":  template.HTML(html),
-		"CSS":      template.CSS(getAsset("app.css")),
+		"metadata":   template.JS(string(meta)),
+		"extraFiles": template.JS(string(extraFiles)),
+		"config":     template.JS(cfg),
+		"content":    template.HTML(files[0].Diff),
+		"CSS":        template.CSS(getAsset("app.css")),
 		"JS": map[string]interface{}{
 			"mithril":   template.JS(getAsset("vendor/mithril.min.js")),
 			"mousetrap": template.JS(getAsset("vendor/mousetrap.min.js")),
@@ -200,8 +407,97 @@ func html(d *delta.DiffSolution, pathFrom, pathTo, pathBase string, config Confi
 	return buf, err
 }
 
+// fileDiff is the diff of two files. If either file is binary, and --hex
+// is not set, binary is set instead of d.
+type fileDiff struct {
+	d      *delta.DiffSolution
+	binary *delta.BinaryDiff
+
+	// fromText and toText are the decoded files, which are not set for
+	// binary files.
+	fromText, toText *delta.Text
+
+	// images are the contents of the files if either is an image, and
+	// --hex is not set. Images are compared visually in html output,
+	// together with d if they are text, e.g. SVG.
+	images [][]byte
+}
+
+// html renders the diff for the delta GUI.
+func (fd *fileDiff) html() string {
+	ctx, cancel := timeoutContext()
+	defer cancel()
+	opts := append(formatterOptions(), formatter.WithContext(ctx))
+	if fd.images != nil {
+		return formatter.ImageHTML(fd.images[0], fd.images[1], fd.d, opts...)
+	}
+	if fd.binary != nil {
+		return formatter.BinaryHTML(fd.binary)
+	}
+	return formatter.HTML(fd.d, opts...)
+}
+
+// text renders the diff as plain text.
+func (fd *fileDiff) text(fromFile, toFile string) string {
+	if fd.binary != nil {
+		return fd.binary.Summary(fromFile, toFile) + "\n"
+	}
+	return formatter.Text(fd.d)
+}
+
+// coloredText renders the diff for display in a terminal.
+func (fd *fileDiff) coloredText(fromFile, toFile string) string {
+	if fd.binary != nil {
+		return fd.binary.Summary(fromFile, toFile) + "\n"
+	}
+	ctx, cancel := timeoutContext()
+	defer cancel()
+	return formatter.ColoredText(fd.d, append(formatterOptions(), formatter.WithContext(ctx))...)
+}
+
+// unified renders the diff as a unified diff of fromFile and toFile.
+func (fd *fileDiff) unified(fromFile, toFile string) string {
+	opts := formatter.UnifiedOptions{
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  *unified,
+		FromText: fd.fromText,
+		ToText:   fd.toText,
+	}
+	if fd.binary != nil {
+		return formatter.BinaryUnified(fd.binary, opts)
+	}
+	return formatter.Unified(fd.d, opts)
+}
+
 // diff reads in files in pathFrom and pathTo, and returns a diff
-func diff(pathFrom, pathTo string) (*delta.DiffSolution, error) {
+// computed using the named algorithm, the whitespace options and the
+// weights, post processed using the indent heuristic if it is set. Moved
+// lines are detected if --color-moved is set. If --timeout is set and
+// diffing takes too long, a coarse diff is returned.
+//
+// If either file is binary, only their sizes and hashes are compared,
+// unless --hex is set, in which case their hex dumps are diffed. Images
+// are also kept for the html output, see delta.ImageType. Otherwise
+// the files are decoded using delta.DecodeText, and are returned together
+// with the diff. Changes of their encodings and line endings are reported
+// on stderr, since they are not visible in the diff.
+func diff(pathFrom, pathTo, algorithm string) (*fileDiff, error) {
+	mode, err := delta.ParseWhitespaceMode(*whitespace)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := delta.ParseGranularity(*granularity); err != nil {
+		return nil, err
+	}
+	w, err := delta.ParseWeights(*weights, delta.DefaultWeights)
+	if err != nil {
+		return nil, err
+	}
+	if *weights != "" && !delta.AlgorithmWeighted(algorithm) {
+		return nil, fmt.Errorf("the %s algorithm does not use weights, valid algorithms for --weights are: %s",
+			algorithm, strings.Join(weightedAlgorithms(), ", "))
+	}
 	from, err := ioutil.ReadFile(pathFrom)
 	if err != nil {
 		return nil, fmt.Errorf("error reading %q: %v", pathFrom, err)
@@ -210,5 +506,42 @@ func diff(pathFrom, pathTo string) (*delta.DiffSolution, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error reading %q: %v", pathTo, err)
 	}
-	return delta.HistogramDiff(string(from), string(to)), nil
+	fd := &fileDiff{}
+	if !*hexDump && (delta.ImageType(from) != "" || delta.ImageType(to) != "") {
+		fd.images = [][]byte{from, to}
+	}
+	if delta.IsBinary(from) || delta.IsBinary(to) {
+		if !*hexDump {
+			fd.binary = delta.NewBinaryDiff(from, to)
+			return fd, nil
+		}
+		fd.d, err = delta.DiffHex(from, to, algorithm)
+		return fd, err
+	}
+	ft, tt := delta.DecodeText(from), delta.DecodeText(to)
+	for _, c := range delta.TextChanges(ft, tt) {
+		fmt.Fprintf(os.Stderr, "note: %s: %s\n", pathTo, c)
+	}
+
+	opts := []delta.Option{
+		delta.WithAlgorithm(algorithm),
+		delta.WithWhitespace(delta.WhitespaceOptions{
+			Mode:             mode,
+			IgnoreCRAtEOL:    *ignoreCRAtEOL,
+			IgnoreBlankLines: *ignoreBlankLines,
+		}),
+		delta.WithWeights(w),
+		delta.WithIndentHeuristic(*indentHeuristic),
+		delta.WithMoveDetection(*colorMoved),
+	}
+	ctx, cancel := timeoutContext()
+	defer cancel()
+	opts = append(opts, delta.WithContext(ctx))
+	fd.d, err = delta.Diff(ft.Content, tt.Content, opts...)
+	if err == context.DeadlineExceeded {
+		fmt.Fprintf(os.Stderr, "warning: diffing %s took longer than %v, showing a coarse diff\n", pathTo, *timeout)
+		err = nil
+	}
+	fd.fromText, fd.toText = &ft, &tt
+	return fd, err
 }
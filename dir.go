@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/octavore/delta/lib"
+
+	"github.com/pkg/browser"
+)
+
+// dirFile is a file in a directory diff, paired by its path relative to
+// the directories being compared. from or to is /dev/null if the file
+// does not exist on that side. Renamed and copied files also have the
+// relative path of the original file.
+type dirFile struct {
+	path       string
+	from, to   string
+	change     change
+	oldPath    string
+	similarity int
+}
+
+// title describes the change to the file.
+func (f *dirFile) title() string {
+	if f.oldPath != "" {
+		return fmt.Sprintf("%s: %s -> %s (%d%%)", f.change, f.oldPath, f.path, f.similarity)
+	}
+	return fmt.Sprintf("%s: %s", f.change, f.path)
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+// listFiles returns the paths of all files under dir, relative to dir.
+// Symlinks are treated as files.
+func listFiles(dir string) (map[string]bool, error) {
+	files := map[string]bool{}
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	return files, err
+}
+
+// walkDirs pairs the files in dirFrom and dirTo by relative path, and
+// classifies each pair. Files are returned sorted by path.
+func walkDirs(dirFrom, dirTo string) ([]*dirFile, error) {
+	filesFrom, err := listFiles(dirFrom)
+	if err != nil {
+		return nil, err
+	}
+	filesTo, err := listFiles(dirTo)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for p := range filesFrom {
+		paths = append(paths, p)
+	}
+	for p := range filesTo {
+		if !filesFrom[p] {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	files := []*dirFile{}
+	for _, p := range paths {
+		f := &dirFile{
+			path: p,
+			from: filepath.Join(dirFrom, filepath.FromSlash(p)),
+			to:   filepath.Join(dirTo, filepath.FromSlash(p)),
+		}
+		switch {
+		case !filesTo[p]:
+			f.to = "/dev/null"
+			f.change = changeDeleted
+		case !filesFrom[p]:
+			f.from = "/dev/null"
+			f.change = changeAdded
+		default:
+			f.change, err = compareFiles(f.from, f.to)
+			if err != nil {
+				return nil, err
+			}
+		}
+		files = append(files, f)
+	}
+	return detectRenames(files)
+}
+
+// detectRenames replaces added files which are similar to deleted files
+// with renamed files. If copies are enabled, added files which are similar
+// to other files in the original directory are marked as copied.
+func detectRenames(files []*dirFile) ([]*dirFile, error) {
+	if *findRenames <= 0 {
+		return files, nil
+	}
+	deleted, added, sources := []delta.RenameFile{}, []delta.RenameFile{}, []delta.RenameFile{}
+	byPath := map[string]*dirFile{}
+	for _, f := range files {
+		byPath[f.path] = f
+		var err error
+		var content string
+		switch {
+		case f.change == changeDeleted:
+			content, err = readFile(f.from)
+			deleted = append(deleted, delta.RenameFile{Name: f.path, Content: content})
+		case f.change == changeAdded:
+			content, err = readFile(f.to)
+			added = append(added, delta.RenameFile{Name: f.path, Content: content})
+		case *findCopies:
+			content, err = readFile(f.from)
+			sources = append(sources, delta.RenameFile{Name: f.path, Content: content})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	renamed := map[*dirFile]bool{}
+	renames := delta.DetectRenames(deleted, added, sources, delta.RenameOptions{
+		Threshold: *findRenames,
+		Copies:    *findCopies,
+	})
+	for _, r := range renames {
+		from, to := byPath[r.From], byPath[r.To]
+		to.from = from.from
+		to.oldPath = from.path
+		to.similarity = r.Similarity
+		to.change = changeRenamed
+		if r.Copy {
+			to.change = changeCopied
+		} else {
+			renamed[from] = true
+		}
+	}
+
+	result := []*dirFile{}
+	for _, f := range files {
+		if !renamed[f] {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+func readFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading %q: %v", path, err)
+	}
+	return string(b), nil
+}
+
+// compareFiles returns changeUnchanged if the files have the same contents,
+// and changeModified otherwise.
+func compareFiles(pathFrom, pathTo string) (change, error) {
+	from, err := readFile(pathFrom)
+	if err != nil {
+		return "", err
+	}
+	to, err := readFile(pathTo)
+	if err != nil {
+		return "", err
+	}
+	if from == to {
+		return changeUnchanged, nil
+	}
+	return changeModified, nil
+}
+
+// dirSummary describes the number of files with each kind of change.
+func dirSummary(files []*dirFile) string {
+	counts := map[change]int{}
+	for _, f := range files {
+		counts[f.change]++
+	}
+	return fmt.Sprintf("%d modified, %d added, %d deleted, %d renamed, %d copied, %d unchanged",
+		counts[changeModified], counts[changeAdded], counts[changeDeleted],
+		counts[changeRenamed], counts[changeCopied], counts[changeUnchanged])
+}
+
+// runDirDiff compares the directories dirFrom and dirTo, and displays the
+// diffs of all changed files in a single report. This allows delta to be
+// used with git difftool --dir-diff.
+func runDirDiff(dirFrom, dirTo string) {
+	config := loadSettings()
+	files, err := walkDirs(dirFrom, dirTo)
+	if err != nil {
+		os.Stderr.WriteString(err.Error())
+		return
+	}
+
+	diffs := map[*dirFile]*fileDiff{}
+	changed := []*dirFile{}
+	for _, f := range files {
+		if f.change == changeUnchanged {
+			continue
+		}
+		fd, err := diff(f.from, f.to, *algorithm)
+		if err != nil {
+			os.Stderr.WriteString(err.Error())
+			return
+		}
+		diffs[f] = fd
+		changed = append(changed, f)
+	}
+
+	switch *format {
+	case FormatOptionHTML:
+		if len(changed) == 0 {
+			os.Stderr.WriteString("no differences found: " + dirSummary(files))
+			return
+		}
+		pageFiles := []*File{}
+		for _, f := range changed {
+			file := newFile(f.from, f.to, f.path, f.change, diffs[f].html())
+			file.Metadata.Similarity = f.similarity
+			pageFiles = append(pageFiles, file)
+		}
+		page, err := page(pageFiles, config)
+		if err != nil {
+			os.Stderr.WriteString(err.Error())
+			return
+		}
+		switch *output {
+		case OutputOptionCLI:
+			page.WriteTo(os.Stdout)
+		case OutputOptionGist:
+			uploadGist(page.Bytes())
+		case OutputOptionBrowser:
+			browser.OpenReader(page)
+		}
+
+	case FormatOptionText:
+		switch *output {
+		case OutputOptionCLI:
+			for _, f := range changed {
+				fmt.Printf("\x1b[1m%s\x1b[0m\n", f.title())
+				fmt.Println(diffs[f].coloredText(f.from, f.to))
+			}
+			fmt.Println(dirSummary(files))
+		case OutputOptionGist:
+			uploadGist([]byte(dirText(changed, diffs, files)))
+		case OutputOptionBrowser:
+			browser.OpenReader(bytes.NewBufferString(dirText(changed, diffs, files)))
+		}
+
+	case FormatOptionUnified:
+		patch := dirPatch(changed, diffs)
+		switch *output {
+		case OutputOptionCLI:
+			fmt.Print(patch)
+		case OutputOptionGist:
+			uploadGist([]byte(patch))
+		case OutputOptionBrowser:
+			browser.OpenReader(bytes.NewBufferString(patch))
+		}
+	}
+}
+
+// dirText renders the changed files as plain text, followed by a summary.
+func dirText(changed []*dirFile, diffs map[*dirFile]*fileDiff, files []*dirFile) string {
+	out := []string{}
+	for _, f := range changed {
+		out = append(out, f.title(), diffs[f].text(f.from, f.to))
+	}
+	out = append(out, dirSummary(files))
+	return strings.Join(out, "\n") + "\n"
+}
+
+// dirPatch renders the changed files as a single unified diff, with paths
+// prefixed by a/ and b/ as in git. Renames and copies are described using
+// git's extended headers.
+func dirPatch(changed []*dirFile, diffs map[*dirFile]*fileDiff) string {
+	patch := ""
+	for _, f := range changed {
+		fromFile, toFile := "a/"+f.path, "b/"+f.path
+		switch f.change {
+		case changeAdded:
+			fromFile = "/dev/null"
+		case changeDeleted:
+			toFile = "/dev/null"
+		case changeRenamed, changeCopied:
+			verb := "rename"
+			if f.change == changeCopied {
+				verb = "copy"
+			}
+			fromFile = "a/" + f.oldPath
+			patch += fmt.Sprintf("diff --git %s %s\nsimilarity index %d%%\n%s from %s\n%s to %s\n",
+				fromFile, toFile, f.similarity, verb, f.oldPath, verb, f.path)
+		}
+		patch += diffs[f].unified(fromFile, toFile)
+	}
+	return patch
+}
@@ -12,18 +12,32 @@ import (
 	"github.com/pkg/browser"
 )
 
+// preserved are git settings which the user may already have set. Their
+// original values are saved in the delta section by installGit, and are
+// restored by uninstallGit.
+var preserved = []struct {
+	key, saved, value string
+}{
+	{"core.pager", "delta.originalPager", "delta | less -FRX"},
+	{"interactive.diffFilter", "delta.originalDiffFilter", "delta"},
+}
+
 func installGit() {
 	commands := [][]string{
 		{"git", "config", "--global", "diff.tool", "delta"},
 		{"git", "config", "--global", "difftool.prompt", "false"},
 		{"git", "config", "--global", "difftool.delta.cmd", `delta "$LOCAL" "$REMOTE" "$MERGED"`},
+		{"git", "config", "--global", "merge.tool", "delta"},
+		{"git", "config", "--global", "mergetool.delta.cmd", `delta --merge "$BASE" "$LOCAL" "$REMOTE" "$MERGED"`},
+		{"git", "config", "--global", "mergetool.delta.trustExitCode", "true"},
 	}
-
-	for _, c := range commands {
-		fmt.Println(strings.Join(c, " "))
-		o, _ := exec.Command(c[0], c[1:]...).CombinedOutput()
-		fmt.Print(string(o))
+	for _, p := range preserved {
+		if v, ok := gitConfigGet(p.key); ok && v != p.value {
+			commands = append(commands, []string{"git", "config", "--global", p.saved, v})
+		}
+		commands = append(commands, []string{"git", "config", "--global", p.key, p.value})
 	}
+	runCommands(commands)
 }
 
 // known issue: this does not remove the gitconfig section if the unset
@@ -33,8 +47,32 @@ func uninstallGit() {
 		{"git", "config", "--global", "--unset", "diff.tool"},
 		{"git", "config", "--global", "--unset", "difftool.prompt"},
 		{"git", "config", "--global", "--remove-section", "difftool.delta"},
+		{"git", "config", "--global", "--unset", "merge.tool"},
+		{"git", "config", "--global", "--remove-section", "mergetool.delta"},
+	}
+	restored := false
+	for _, p := range preserved {
+		if v, ok := gitConfigGet(p.saved); ok {
+			commands = append(commands, []string{"git", "config", "--global", p.key, v})
+			restored = true
+		} else {
+			commands = append(commands, []string{"git", "config", "--global", "--unset", p.key})
+		}
+	}
+	if restored {
+		commands = append(commands, []string{"git", "config", "--global", "--remove-section", "delta"})
 	}
+	runCommands(commands)
+}
+
+// gitConfigGet returns the value of key in the global gitconfig, and
+// whether it is set.
+func gitConfigGet(key string) (string, bool) {
+	o, err := exec.Command("git", "config", "--global", "--get", key).Output()
+	return strings.TrimSuffix(string(o), "\n"), err == nil
+}
 
+func runCommands(commands [][]string) {
 	for _, c := range commands {
 		fmt.Println(strings.Join(c, " "))
 		o, _ := exec.Command(c[0], c[1:]...).CombinedOutput()
@@ -0,0 +1,61 @@
+package delta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Apply applies the solution to original, which must match the A side of
+// the solution, and returns the B side. This can be used to reconstruct a
+// file from its previous revision and a stored DiffSolution.
+func Apply(original string, d *DiffSolution) (string, error) {
+	a, b := d.sides()
+	if err := verifySide(original, a); err != nil {
+		return "", err
+	}
+	return strings.Join(b, "\n"), nil
+}
+
+// ApplyReverse applies the solution in reverse to modified, which must match
+// the B side of the solution, and returns the A side.
+func ApplyReverse(modified string, d *DiffSolution) (string, error) {
+	a, b := d.sides()
+	if err := verifySide(modified, b); err != nil {
+		return "", err
+	}
+	return strings.Join(a, "\n"), nil
+}
+
+// sides returns the lines of A and B in the solution.
+func (d *DiffSolution) sides() (a, b []string) {
+	for _, l := range d.TypedLines() {
+		if l.InA() {
+			a = append(a, l.A)
+		}
+		if l.InB() {
+			b = append(b, l.B)
+		}
+	}
+	return a, b
+}
+
+// verifySide returns an error if the content does not match the given
+// lines, identifying the first line which differs.
+func verifySide(content string, lines []string) error {
+	cl := strings.Split(content, "\n")
+	if len(lines) == 0 {
+		// an empty solution corresponds to empty content
+		lines = []string{""}
+	}
+	for i := 0; i < len(cl) || i < len(lines); i++ {
+		switch {
+		case i >= len(lines):
+			return fmt.Errorf("delta: solution ends before line %d", i+1)
+		case i >= len(cl):
+			return fmt.Errorf("delta: content ends before line %d of solution", i+1)
+		case cl[i] != lines[i]:
+			return fmt.Errorf("delta: line %d does not match solution: %q != %q", i+1, cl[i], lines[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package delta
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	words := []string{"a", "b", "  a", "}", ""}
+	randomContent := func() string {
+		l := make([]string, r.Intn(20))
+		for i := range l {
+			l[i] = words[r.Intn(len(words))]
+		}
+		return strings.Join(l, "\n")
+	}
+
+	for _, name := range Algorithms() {
+		diff, _ := Algorithm(name)
+		for i := 0; i < 100; i++ {
+			a, b := randomContent(), randomContent()
+			d := diff(a, b)
+			if applied, err := Apply(a, d); err != nil || applied != b {
+				t.Fatalf("%s: expected Apply(%q) to return %q but got %q, %v", name, a, b, applied, err)
+			}
+			if reversed, err := ApplyReverse(b, d); err != nil || reversed != a {
+				t.Fatalf("%s: expected ApplyReverse(%q) to return %q but got %q, %v", name, b, a, reversed, err)
+			}
+		}
+	}
+}
+
+func TestApplyMismatch(t *testing.T) {
+	d := HistogramDiff("a\nb\nc", "a\nc")
+	if _, err := Apply("a\nx\nc", d); err == nil {
+		t.Error("expected error applying to mismatched content")
+	}
+	if _, err := Apply("a\nb", d); err == nil {
+		t.Error("expected error applying to truncated content")
+	}
+}
@@ -0,0 +1,150 @@
+package delta
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// binarySniffLen is the number of bytes checked by IsBinary, as in git.
+const binarySniffLen = 8000
+
+// IsBinary returns true if data looks like the contents of a binary file
+// rather than text. Like git, data is binary if it has a NUL byte near the
+// start, unless it is detected as UTF-16 by DecodeText. Data which is not
+// valid UTF-8 is also binary if more than one in twenty bytes are control
+// characters, which are rare in Latin-1 text.
+func IsBinary(data []byte) bool {
+	switch detectEncoding(data) {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		return false
+	}
+	sample := data
+	if len(sample) > binarySniffLen {
+		sample = sample[:binarySniffLen]
+	}
+	if bytes.IndexByte(sample, 0) != -1 {
+		return true
+	}
+	if utf8.Valid(data) {
+		return false
+	}
+	controls := 0
+	for _, b := range sample {
+		if (b < 0x20 && !strings.ContainsRune("\t\n\r\f\b\x1b", rune(b))) || b == 0x7f {
+			controls++
+		}
+	}
+	return controls*20 > len(sample)
+}
+
+// BinaryFile summarizes the contents of a binary file.
+type BinaryFile struct {
+	Size   int
+	SHA256 string // hex encoded
+}
+
+// NewBinaryFile returns the summary of data.
+func NewBinaryFile(data []byte) BinaryFile {
+	h := sha256.Sum256(data)
+	return BinaryFile{Size: len(data), SHA256: hex.EncodeToString(h[:])}
+}
+
+// String returns e.g. "1024 bytes, sha256 0123456789ab", with the hash
+// abbreviated to 12 digits.
+func (f BinaryFile) String() string {
+	return fmt.Sprintf("%d bytes, sha256 %s", f.Size, f.SHA256[:12])
+}
+
+// BinaryDiff compares two files, at least one of which is binary.
+type BinaryDiff struct {
+	From, To BinaryFile
+}
+
+// NewBinaryDiff returns the BinaryDiff of the contents a and b.
+func NewBinaryDiff(a, b []byte) *BinaryDiff {
+	return &BinaryDiff{From: NewBinaryFile(a), To: NewBinaryFile(b)}
+}
+
+// Changed returns true if the files differ.
+func (b *BinaryDiff) Changed() bool {
+	return b.From != b.To
+}
+
+// Summary describes the files like diff, with their sizes and hashes, e.g.
+//
+//	Binary files a.png and b.png differ (1024 bytes, sha256 0123456789ab -> 1100 bytes, sha256 ba9876543210)
+func (b *BinaryDiff) Summary(fromFile, toFile string) string {
+	verb := "differ"
+	if !b.Changed() {
+		verb = "are identical"
+	}
+	return fmt.Sprintf("Binary files %s and %s %s (%s -> %s)", fromFile, toFile, verb, b.From, b.To)
+}
+
+// HexRowSize is the number of bytes in each row of a hex dump.
+const HexRowSize = 16
+
+// HexDump formats a row of at most HexRowSize bytes like hexdump -C, but
+// without the offset.
+func HexDump(row []byte) string {
+	buf := &strings.Builder{}
+	for i := 0; i < HexRowSize; i++ {
+		if i == HexRowSize/2 {
+			buf.WriteByte(' ')
+		}
+		if i < len(row) {
+			fmt.Fprintf(buf, "%02x ", row[i])
+		} else {
+			buf.WriteString("   ")
+		}
+	}
+	buf.WriteString(" |")
+	for _, c := range row {
+		if c < 0x20 || c > 0x7e {
+			c = '.'
+		}
+		buf.WriteByte(c)
+	}
+	buf.WriteString("|")
+	return buf.String()
+}
+
+// DiffHex diffs a and b as hex dumps, using the named algorithm to diff
+// rows of HexRowSize bytes. Line n of the result is the row at offset
+// HexRowSize*(n-1) of A and B. Since rows are compared exactly, bytes which
+// are inserted or deleted make the following rows differ unless a multiple
+// of HexRowSize bytes is inserted or deleted.
+func DiffHex(a, b []byte, algorithm string) (*DiffSolution, error) {
+	rows := func(data []byte) []string {
+		r := []string{}
+		for i := 0; i < len(data); i += HexRowSize {
+			r = append(r, string(data[i:min(i+HexRowSize, len(data))]))
+		}
+		return r
+	}
+	ar, br := rows(a), rows(b)
+	differ := NewComparableDiffer(ar, br)
+	if err := differ.SetAlgorithm(algorithm); err != nil {
+		return nil, err
+	}
+	d := &DiffSolution{}
+	for _, e := range differ.Edits() {
+		for i := 0; i < max(e.AEnd-e.AStart, e.BEnd-e.BStart); i++ {
+			l := Line{Source: e.Op}
+			if l.InA() {
+				l.A = HexDump([]byte(ar[e.AStart+i]))
+			}
+			if l.InB() {
+				l.B = HexDump([]byte(br[e.BStart+i]))
+			}
+			d.addLine(l.A, l.B, l.Source)
+		}
+	}
+	// each row of the dump ends with a newline, like the lines of a file
+	d.addLine("", "", LineFromBoth)
+	return d, nil
+}
@@ -0,0 +1,60 @@
+package delta
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		data string
+		e    bool
+	}{
+		{"a\nb\n", false},
+		{"", false},
+		{"caf\xe9 cr\xe8me\r\n", false},
+		{"\xff\xfea\x00\n\x00", false},
+		{"\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", true},
+		{"a\x00b", true},
+		{"\xde\xad\xbe\xef\x01\x02\x03", true},
+	}
+	for _, tt := range tests {
+		if b := IsBinary([]byte(tt.data)); b != tt.e {
+			t.Errorf("%q: expected %v but got %v", tt.data, tt.e, b)
+		}
+	}
+}
+
+func TestBinaryDiffSummary(t *testing.T) {
+	b := NewBinaryDiff([]byte("a\x00"), []byte("b\x00c"))
+	e := "Binary files x and y differ (2 bytes, sha256 ffe9aaeaa2a2 -> 3 bytes, sha256 2b7d2121198c)"
+	if s := b.Summary("x", "y"); s != e || !b.Changed() {
+		t.Errorf("expected %q but got %q", e, s)
+	}
+	if b := NewBinaryDiff([]byte("a"), []byte("a")); b.Changed() {
+		t.Errorf("expected identical files to be unchanged")
+	}
+}
+
+func TestDiffHex(t *testing.T) {
+	a := []byte{}
+	for _, c := range []byte{0x00, 0x11, 0x22} {
+		a = append(a, bytes.Repeat([]byte{c}, HexRowSize)...)
+	}
+	b := append(append([]byte{}, a...), "hello"...)
+	b[HexRowSize] = 0x41
+	d, err := DiffHex(a, b, "histogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := ` 00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|
+-11 11 11 11 11 11 11 11  11 11 11 11 11 11 11 11  |................|
++41 11 11 11 11 11 11 11  11 11 11 11 11 11 11 11  |A...............|
+ 22 22 22 22 22 22 22 22  22 22 22 22 22 22 22 22  |""""""""""""""""|
++68 65 6c 6c 6f                                    |hello|
+ 
+`
+	if r := renderLines(d.TypedLines()); r != e {
+		t.Errorf("expected:\n%s\nbut got:\n%s", e, r)
+	}
+}
@@ -0,0 +1,265 @@
+package delta
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultCleanup is the aggressiveness of CleanupSemantic used by the
+// formatters. It matches diff-match-patch's semantic cleanup.
+const DefaultCleanup = 1.0
+
+// segment is a run of text which is equal, deleted from A or added to B.
+type segment struct {
+	op   LineSource // LineFromBoth, LineFromA or LineFromB
+	text string
+}
+
+// CleanupSemantic makes an intra-line diff, e.g. the result of DiffLine,
+// easier to read, like diff-match-patch's diff_cleanupSemantic:
+//
+//   - Short equalities between changes are merged into the changes. An
+//     equality is merged if it is at most aggressiveness times as long as
+//     the changes on either side of it, so 0 never merges equalities and
+//     larger values merge longer ones.
+//   - Single insertions and deletions are shifted to word boundaries, e.g.
+//     "the c[at c]ame" becomes "the [cat ]came".
+//
+// Each line of the result is a run of equal, edited, deleted or added text,
+// so the solution can no longer be applied to the original tokens.
+func (d *DiffSolution) CleanupSemantic(aggressiveness float64) {
+	segs := []segment{}
+	for _, l := range d.TypedLines() {
+		if l.Source == LineFromBoth {
+			segs = append(segs, segment{LineFromBoth, l.A})
+			continue
+		}
+		if l.InA() {
+			segs = append(segs, segment{LineFromA, l.A})
+		}
+		if l.InB() {
+			segs = append(segs, segment{LineFromB, l.B})
+		}
+	}
+	segs = mergeSegments(segs)
+	segs = mergeEqualities(segs, aggressiveness)
+	segs = alignSegments(segs)
+
+	d.Lines = nil
+	for i := 0; i < len(segs); i++ {
+		s := segs[i]
+		if s.op == LineFromA && i+1 < len(segs) && segs[i+1].op == LineFromB {
+			d.addLine(s.text, segs[i+1].text, LineFromBothEdit)
+			i++
+			continue
+		}
+		switch s.op {
+		case LineFromBoth:
+			d.addLine(s.text, s.text, LineFromBoth)
+		case LineFromA:
+			d.addLineA(s.text)
+		case LineFromB:
+			d.addLineB(s.text)
+		}
+	}
+}
+
+// mergeSegments joins adjacent segments of the same kind, and reorders each
+// run of changes into one deletion followed by one insertion. Empty
+// segments are removed.
+func mergeSegments(segs []segment) []segment {
+	merged := []segment{}
+	del, ins := &strings.Builder{}, &strings.Builder{}
+	flush := func() {
+		if del.Len() > 0 {
+			merged = append(merged, segment{LineFromA, del.String()})
+		}
+		if ins.Len() > 0 {
+			merged = append(merged, segment{LineFromB, ins.String()})
+		}
+		del.Reset()
+		ins.Reset()
+	}
+	for _, s := range segs {
+		switch s.op {
+		case LineFromA:
+			del.WriteString(s.text)
+		case LineFromB:
+			ins.WriteString(s.text)
+		default:
+			if s.text == "" {
+				continue
+			}
+			flush()
+			if last := len(merged) - 1; last >= 0 && merged[last].op == LineFromBoth {
+				merged[last].text += s.text
+			} else {
+				merged = append(merged, s)
+			}
+		}
+	}
+	flush()
+	return merged
+}
+
+// changeRun is a deletion and an insertion between two equalities. The
+// texts are kept as pieces, so that runs can be joined in constant time.
+type changeRun struct {
+	del, ins   []string
+	delN, insN int // lengths in runes
+}
+
+func (c *changeRun) add(op LineSource, text string) {
+	if op == LineFromA {
+		c.del = append(c.del, text)
+		c.delN += utf8.RuneCountInString(text)
+	} else {
+		c.ins = append(c.ins, text)
+		c.insN += utf8.RuneCountInString(text)
+	}
+}
+
+// len returns the length of the larger of the deletion and the insertion.
+func (c changeRun) len() int {
+	return max(c.delN, c.insN)
+}
+
+// join returns the run of changes c, followed by the equality eq and the
+// changes d, with eq both deleted and inserted. c must not be used again.
+func (c changeRun) join(eq string, d changeRun) changeRun {
+	c.add(LineFromA, eq)
+	c.add(LineFromB, eq)
+	c.del, c.ins = append(c.del, d.del...), append(c.ins, d.ins...)
+	c.delN, c.insN = c.delN+d.delN, c.insN+d.insN
+	return c
+}
+
+// mergeEqualities replaces equalities which are short compared to the
+// changes on both sides with a deletion and insertion of their text. The
+// segments are scanned once, and like diff-match-patch, the previous
+// equality is checked again after each merge, since the changes after it
+// have grown.
+func mergeEqualities(segs []segment, aggressiveness float64) []segment {
+	mergeable := func(eq string, before, after changeRun) bool {
+		n := float64(utf8.RuneCountInString(eq))
+		return before.len() > 0 && after.len() > 0 &&
+			n <= aggressiveness*float64(before.len()) && n <= aggressiveness*float64(after.len())
+	}
+
+	// changes[i] is the run of changes before eqs[i], and the last run is
+	// after the last equality
+	eqs, changes := []string{}, []changeRun{{}}
+	for i := 0; i < len(segs); {
+		eq := ""
+		if segs[i].op == LineFromBoth {
+			eq = segs[i].text
+			i++
+		}
+		c := changeRun{}
+		for ; i < len(segs) && segs[i].op != LineFromBoth; i++ {
+			c.add(segs[i].op, segs[i].text)
+		}
+
+		last := len(changes) - 1
+		if eq == "" {
+			// only the first run of changes has no equality before it
+			changes[last] = c
+			continue
+		}
+		if !mergeable(eq, changes[last], c) {
+			eqs = append(eqs, eq)
+			changes = append(changes, c)
+			continue
+		}
+		changes[last] = changes[last].join(eq, c)
+		for len(eqs) > 0 {
+			last = len(changes) - 1
+			eq = eqs[len(eqs)-1]
+			if !mergeable(eq, changes[last-1], changes[last]) {
+				break
+			}
+			changes[last-1] = changes[last-1].join(eq, changes[last])
+			eqs, changes = eqs[:len(eqs)-1], changes[:last]
+		}
+	}
+
+	merged := []segment{}
+	for i, c := range changes {
+		if i > 0 {
+			merged = append(merged, segment{LineFromBoth, eqs[i-1]})
+		}
+		merged = append(merged,
+			segment{LineFromA, strings.Join(c.del, "")},
+			segment{LineFromB, strings.Join(c.ins, "")})
+	}
+	return mergeSegments(merged)
+}
+
+// alignSegments shifts single insertions and deletions between two
+// equalities sideways, to the position which best fits word boundaries.
+func alignSegments(segs []segment) []segment {
+	for i := 1; i < len(segs)-1; i++ {
+		if segs[i-1].op != LineFromBoth || segs[i].op == LineFromBoth || segs[i+1].op != LineFromBoth {
+			continue
+		}
+		before, edit, after := segs[i-1].text, segs[i].text, segs[i+1].text
+
+		// shift the edit as far left as possible
+		// characters are compared as bytes, since all invalid UTF-8 decodes
+		// to utf8.RuneError
+		for before != "" {
+			_, n := utf8.DecodeLastRuneInString(before)
+			if !strings.HasSuffix(edit, before[len(before)-n:]) {
+				break
+			}
+			before, edit, after = before[:len(before)-n], before[len(before)-n:]+edit[:len(edit)-n], edit[len(edit)-n:]+after
+		}
+
+		// then step right, keeping the best position
+		best := [3]string{before, edit, after}
+		bestScore := boundaryScore(before, edit) + boundaryScore(edit, after)
+		for after != "" {
+			_, n := utf8.DecodeRuneInString(after)
+			if !strings.HasPrefix(edit, after[:n]) {
+				break
+			}
+			before, edit, after = before+edit[:n], edit[n:]+after[:n], after[n:]
+			if score := boundaryScore(before, edit) + boundaryScore(edit, after); score >= bestScore {
+				best, bestScore = [3]string{before, edit, after}, score
+			}
+		}
+		segs[i-1].text, segs[i].text, segs[i+1].text = best[0], best[1], best[2]
+	}
+	return mergeSegments(segs)
+}
+
+// boundaryScore scores the boundary between the texts a and b, from 6 for
+// the start or end of the text to 0 for the middle of a word.
+func boundaryScore(a, b string) int {
+	if a == "" || b == "" {
+		return 6
+	}
+	r1, _ := utf8.DecodeLastRuneInString(a)
+	r2, _ := utf8.DecodeRuneInString(b)
+	nonWord1 := !unicode.IsLetter(r1) && !unicode.IsNumber(r1)
+	nonWord2 := !unicode.IsLetter(r2) && !unicode.IsNumber(r2)
+	space1 := nonWord1 && unicode.IsSpace(r1)
+	space2 := nonWord2 && unicode.IsSpace(r2)
+	lineBreak1 := r1 == '\n' || r1 == '\r'
+	lineBreak2 := r2 == '\n' || r2 == '\r'
+	switch {
+	case strings.HasSuffix(a, "\n\n") || strings.HasSuffix(a, "\n\r\n") ||
+		strings.HasPrefix(b, "\n\n") || strings.HasPrefix(b, "\r\n\r\n"):
+		return 5 // blank line
+	case lineBreak1 || lineBreak2:
+		return 4
+	case nonWord1 && !space1 && space2:
+		return 3 // end of sentence
+	case space1 || space2:
+		return 2
+	case nonWord1 || nonWord2:
+		return 1
+	}
+	return 0
+}
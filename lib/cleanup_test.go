@@ -0,0 +1,82 @@
+package delta
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCleanupSemantic(t *testing.T) {
+	a := "one two three four"
+	b := "uno two tres four"
+	tests := []struct {
+		aggressiveness float64
+		e              [][3]string
+	}{
+		{0, [][3]string{
+			{"o", "u", "~"}, {"n", "n", "="}, {"e", "o", "~"}, {" two t", " two t", "="},
+			{"h", "", "<"}, {"re", "re", "="}, {"e", "s", "~"}, {" four", " four", "="},
+		}},
+		{1, [][3]string{
+			{"one", "uno", "~"}, {" two t", " two t", "="},
+			{"h", "", "<"}, {"re", "re", "="}, {"e", "s", "~"}, {" four", " four", "="},
+		}},
+		{3, [][3]string{{"one two three", "uno two tres", "~"}, {" four", " four", "="}}},
+	}
+	for _, tt := range tests {
+		d := DiffLineGranularity(a, b, GranularityChar)
+		d.CleanupSemantic(tt.aggressiveness)
+		if !reflect.DeepEqual(d.Lines, tt.e) {
+			t.Errorf("%v: expected:\n%q\nbut got:\n%q", tt.aggressiveness, tt.e, d.Lines)
+		}
+	}
+}
+
+func TestCleanupSemanticAlign(t *testing.T) {
+	d := NewDiffSolution([]Line{
+		{A: "the c", B: "the c", Source: LineFromBoth},
+		{A: "at c", Source: LineFromA},
+		{A: "ame", B: "ame", Source: LineFromBoth},
+	})
+	d.CleanupSemantic(DefaultCleanup)
+	e := [][3]string{{"the ", "the ", "="}, {"cat ", "", "<"}, {"came", "came", "="}}
+	if !reflect.DeepEqual(d.Lines, e) {
+		t.Errorf("expected:\n%q\nbut got:\n%q", e, d.Lines)
+	}
+}
+
+func TestCleanupSemanticCascade(t *testing.T) {
+	// merging "d" grows the changes after "bbb", which is then merged too
+	d := NewDiffSolution([]Line{
+		{A: "zz", B: "zz", Source: LineFromBoth},
+		{A: "aaa", Source: LineFromA},
+		{A: "bbb", B: "bbb", Source: LineFromBoth},
+		{A: "cc", Source: LineFromA},
+		{A: "d", B: "d", Source: LineFromBoth},
+		{A: "eeee", Source: LineFromA},
+	})
+	d.CleanupSemantic(DefaultCleanup)
+	e := [][3]string{{"zz", "zz", "="}, {"aaabbbccdeeee", "bbbd", "~"}}
+	if !reflect.DeepEqual(d.Lines, e) {
+		t.Errorf("expected:\n%q\nbut got:\n%q", e, d.Lines)
+	}
+}
+
+func TestCleanupSemanticRoundTrip(t *testing.T) {
+	tests := [][2]string{
+		{"b\xe9\xff", "b\xff"},
+		{"caf\xe9 au lait", "cafe\xff au lait"},
+		{"\xe9\xe9x\xff", "\xff\xe9x\xe9"},
+		{"one two three", "uno two tres"},
+	}
+	for _, tt := range tests {
+		for _, g := range granularities {
+			d := DiffLineGranularity(tt[0], tt[1], g)
+			d.CleanupSemantic(DefaultCleanup)
+			a, b := d.sides()
+			if strings.Join(a, "") != tt[0] || strings.Join(b, "") != tt[1] {
+				t.Errorf("%s: expected %q and %q but got %q and %q", g, tt[0], tt[1], a, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package delta
+
+import (
+	"context"
+)
+
+// ContextSolver is a Solver which can be cancelled.
+type ContextSolver interface {
+	Solver
+
+	// SolveContext is like Solve, but stops early if ctx is done. In that
+	// case it returns a coarse solution, which only matches the common
+	// prefix and suffix of the inputs, together with ctx.Err().
+	SolveContext(ctx context.Context) (*DiffSolution, error)
+}
+
+var (
+	_ ContextSolver = &HistogramDiffer{}
+	_ ContextSolver = &SequenceDiffer{}
+	_ ContextSolver = &MyersDiffer{}
+	_ ContextSolver = &PatienceDiffer{}
+)
+
+// SolveContext solves s, stopping early if ctx is done and s is a
+// ContextSolver. Other solvers cannot be stopped, so ctx is only checked
+// before solving, and no solution is returned if it is done.
+func SolveContext(ctx context.Context, s Solver) (*DiffSolution, error) {
+	if cs, ok := s.(ContextSolver); ok {
+		return cs.SolveContext(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Solve(), nil
+}
+
+// checkInterval is the amount of work (roughly, the number of lines
+// compared) between checks of the context.
+const checkInterval = 1 << 14
+
+// cancellation checks whether the context of a solver is done. The methods
+// may be called on a nil *cancellation, which is never done.
+type cancellation struct {
+	ctx  context.Context
+	work int
+	err  error
+}
+
+// done adds the given amount of work, and returns true if the context is
+// done. Checking the context is relatively expensive, so it is checked
+// once every checkInterval.
+func (c *cancellation) done(work int) bool {
+	if c == nil {
+		return false
+	}
+	if c.err != nil {
+		return true
+	}
+	c.work += work
+	if c.work >= checkInterval {
+		c.work = 0
+		c.err = c.ctx.Err()
+	}
+	return c.err != nil
+}
+
+// stopped returns true if done has returned true.
+func (c *cancellation) stopped() bool {
+	return c != nil && c.err != nil
+}
+
+// coarseSolution returns a diff of a and b which only matches their common
+// prefix and suffix. It is returned if a solver is cancelled.
+func coarseSolution(a, b []string, w WhitespaceOptions) *DiffSolution {
+	if w.IgnoreBlankLines {
+		return solveWithoutBlankLines(a, b, w, func(a, b []string) *DiffSolution {
+			return coarseSolution(a, b, w.subregion())
+		})
+	}
+	ak, bk := w.ids(a, b)
+	return coarseScript(ak, bk).solution(a, b)
+}
@@ -0,0 +1,47 @@
+package delta
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSolveContext(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gen := func() string {
+		lines := []string{"header"}
+		for i := 0; i < 1500; i++ {
+			lines = append(lines, strconv.Itoa(r.Intn(100)))
+		}
+		return strings.Join(append(lines, "footer", ""), "\n")
+	}
+	a, b := gen(), gen()
+	aw, bw := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, name := range Algorithms() {
+		f := algorithms[name]
+		d, err := SolveContext(context.Background(), f(aw, bw, DefaultWhitespace))
+		if err != nil || !reflect.DeepEqual(d, f(aw, bw, DefaultWhitespace).Solve()) {
+			t.Errorf("%s: expected the same solution as Solve: %v", name, err)
+		}
+
+		// the coarse solution only matches the first and last lines
+		d, err = SolveContext(cancelled, f(aw, bw, DefaultWhitespace))
+		if err != context.Canceled {
+			t.Errorf("%s: expected context.Canceled but got %v", name, err)
+		}
+		lines := d.TypedLines()
+		if n := len(lines); n != len(aw)+len(bw)-3 ||
+			lines[0].Source != LineFromBoth || lines[n-2].Source != LineFromBoth {
+			t.Errorf("%s: unexpected coarse solution", name)
+		}
+		if r, err := Apply(a, d); err != nil || r != b {
+			t.Errorf("%s: unexpected result: %v", name, err)
+		}
+	}
+}
@@ -1,11 +1,105 @@
 package delta
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // Solver is an interface implemented by the diff algorithms.
 type Solver interface {
 	Solve() *DiffSolution
 }
 
+// WhitespaceSolver is a Solver which supports WhitespaceOptions.
+type WhitespaceSolver interface {
+	Solver
+	SetWhitespace(w WhitespaceOptions)
+}
+
+// WeightedSolver is a Solver which uses Weights, either directly or to
+// diff regions between matches.
+type WeightedSolver interface {
+	Solver
+	SetWeights(w Weights)
+}
+
+// PostProcessSolver is a Solver which calls PostProcess on its solution,
+// and allows disabling it.
+type PostProcessSolver interface {
+	Solver
+	SetPostProcess(enabled bool)
+}
+
 var (
-	_ Solver = &HistogramDiffer{}
-	_ Solver = &SequenceDiffer{}
+	_ WhitespaceSolver = &HistogramDiffer{}
+	_ WhitespaceSolver = &SequenceDiffer{}
+	_ WhitespaceSolver = &MyersDiffer{}
+	_ WhitespaceSolver = &PatienceDiffer{}
+
+	_ WeightedSolver = &HistogramDiffer{}
+	_ WeightedSolver = &SequenceDiffer{}
+	_ WeightedSolver = &PatienceDiffer{}
+
+	_ PostProcessSolver = &HistogramDiffer{}
+	_ PostProcessSolver = &PatienceDiffer{}
 )
+
+// DiffFunc diffs two strings and returns a DiffSolution, e.g. HistogramDiff.
+type DiffFunc func(a, b string) *DiffSolution
+
+// NewSolverFunc returns a Solver which diffs the lines a and b, comparing
+// whitespace as configured by w.
+type NewSolverFunc func(a, b []string, w WhitespaceOptions) Solver
+
+// algorithms contains the NewSolverFuncs registered with RegisterAlgorithm.
+var algorithms = map[string]NewSolverFunc{}
+
+// RegisterAlgorithm makes a diff algorithm available by the given name.
+// It panics if an algorithm is registered twice under the same name.
+func RegisterAlgorithm(name string, f NewSolverFunc) {
+	if _, ok := algorithms[name]; ok {
+		panic("delta: algorithm registered twice: " + name)
+	}
+	algorithms[name] = f
+}
+
+// Algorithms returns the sorted names of the registered algorithms.
+func Algorithms() []string {
+	names := []string{}
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Algorithm returns the diff algorithm registered under the given name,
+// which compares lines using DefaultWhitespace.
+func Algorithm(name string) (DiffFunc, error) {
+	return AlgorithmWhitespace(name, DefaultWhitespace)
+}
+
+// AlgorithmWhitespace returns the diff algorithm registered under the given
+// name, which compares lines as configured by w.
+func AlgorithmWhitespace(name string, w WhitespaceOptions) (DiffFunc, error) {
+	f, ok := algorithms[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown algorithm %q, valid algorithms are: %s",
+			name, strings.Join(Algorithms(), ", "))
+	}
+	return func(a, b string) *DiffSolution {
+		return f(strings.Split(a, "\n"), strings.Split(b, "\n"), w).Solve()
+	}, nil
+}
+
+// AlgorithmWeighted returns true if the algorithm registered under the
+// given name uses Weights, i.e. its Solver is a WeightedSolver.
+func AlgorithmWeighted(name string) bool {
+	f, ok := algorithms[name]
+	if !ok {
+		return false
+	}
+	_, ok = f(nil, nil, DefaultWhitespace).(WeightedSolver)
+	return ok
+}
@@ -0,0 +1,86 @@
+package delta
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// replaceSolver deletes all lines of a and adds all lines of b.
+type replaceSolver struct {
+	a, b []string
+}
+
+func (s *replaceSolver) Solve() *DiffSolution {
+	d := &DiffSolution{}
+	for _, l := range s.a {
+		d.addLineA(l)
+	}
+	for _, l := range s.b {
+		d.addLineB(l)
+	}
+	return d
+}
+
+func TestRegisterAlgorithm(t *testing.T) {
+	var whitespace WhitespaceOptions
+	RegisterAlgorithm("replace", func(a, b []string, w WhitespaceOptions) Solver {
+		whitespace = w
+		return &replaceSolver{a, b}
+	})
+	defer delete(algorithms, "replace")
+
+	e := []string{"histogram", "myers", "patience", "replace", "sequence"}
+	if names := Algorithms(); !reflect.DeepEqual(names, e) {
+		t.Errorf("expected %q but got %q", e, names)
+	}
+
+	w := WhitespaceOptions{Mode: WhitespaceIgnoreAll}
+	f, err := AlgorithmWhitespace("replace", w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	el := [][3]string{{"a", "", "<"}, {"", "b", ">"}}
+	if d := f("a", "b"); !reflect.DeepEqual(d.Lines, el) || whitespace != w {
+		t.Errorf("unexpected solution %q with whitespace %+v", d.Lines, whitespace)
+	}
+	if AlgorithmWeighted("replace") {
+		t.Errorf("expected replace not to use weights")
+	}
+	d, err := Diff("a", "b", WithAlgorithm("replace"))
+	if err != nil || !reflect.DeepEqual(d.Lines, el) {
+		t.Errorf("unexpected solution %q: %v", d.Lines, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering an algorithm twice to panic")
+		}
+	}()
+	RegisterAlgorithm("replace", nil)
+}
+
+func TestAlgorithmUnknown(t *testing.T) {
+	_, err := Algorithm("unknown")
+	if err == nil {
+		t.Fatal("expected error for unknown algorithm")
+	}
+	for _, name := range Algorithms() {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected the error %q to list %s", err, name)
+		}
+	}
+}
+
+func TestAlgorithmWeighted(t *testing.T) {
+	weighted := []string{}
+	for _, name := range Algorithms() {
+		if AlgorithmWeighted(name) {
+			weighted = append(weighted, name)
+		}
+	}
+	e := []string{"histogram", "patience", "sequence"}
+	if !reflect.DeepEqual(weighted, e) {
+		t.Errorf("expected %q but got %q", e, weighted)
+	}
+}
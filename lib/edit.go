@@ -0,0 +1,130 @@
+package delta
+
+// Edit is an operation of an edit script, which describes how the tokens
+// A[AStart:AEnd] correspond to the tokens B[BStart:BEnd]. Op is one of:
+//
+//   - LineFromBoth: the tokens are equal
+//   - LineFromBothEdit: each token of A is changed into a token of B
+//   - LineFromA: the tokens of A are deleted, and BStart == BEnd
+//   - LineFromB: the tokens of B are added, and AStart == AEnd
+//
+// Edits returned by DiffSolution.Edits may also use the other LineSources.
+type Edit struct {
+	Op           LineSource
+	AStart, AEnd int
+	BStart, BEnd int
+}
+
+// script builds an edit script. Edits are added in order, and consecutive
+// edits of the same kind are merged.
+type script struct {
+	edits []Edit
+	a, b  int // the number of tokens of A and B covered by edits
+}
+
+// add adds n tokens of the given kind.
+func (s *script) add(op LineSource, n int) {
+	if n == 0 {
+		return
+	}
+	e := Edit{Op: op, AStart: s.a, AEnd: s.a, BStart: s.b, BEnd: s.b}
+	l := Line{Source: op}
+	if l.InA() {
+		e.AEnd += n
+	}
+	if l.InB() {
+		e.BEnd += n
+	}
+	s.a, s.b = e.AEnd, e.BEnd
+	if last := len(s.edits) - 1; last >= 0 && s.edits[last].Op == op {
+		s.edits[last].AEnd, s.edits[last].BEnd = e.AEnd, e.BEnd
+		return
+	}
+	s.edits = append(s.edits, e)
+}
+
+// addRest adds the tokens after the end of the script as deleted from A
+// and added to B.
+func (s *script) addRest(a, b int) {
+	s.add(LineFromA, a-s.a)
+	s.add(LineFromB, b-s.b)
+}
+
+// solution returns the lines of the script as a DiffSolution, where a and b
+// are the lines which were diffed.
+func (s *script) solution(a, b []string) *DiffSolution {
+	d := &DiffSolution{}
+	for _, e := range s.edits {
+		switch e.Op {
+		case LineFromA:
+			for _, l := range a[e.AStart:e.AEnd] {
+				d.addLineA(l)
+			}
+		case LineFromB:
+			for _, l := range b[e.BStart:e.BEnd] {
+				d.addLineB(l)
+			}
+		default:
+			for i := 0; i < e.AEnd-e.AStart; i++ {
+				d.addLine(a[e.AStart+i], b[e.BStart+i], e.Op)
+			}
+		}
+	}
+	return d
+}
+
+// postProcess is like DiffSolution.PostProcess, where a and b are the
+// token ids which were diffed.
+func (s *script) postProcess(a, b []int) {
+	lines := []Line{}
+	for _, e := range s.edits {
+		for i := 0; i < max(e.AEnd-e.AStart, e.BEnd-e.BStart); i++ {
+			l := Line{Source: e.Op}
+			if l.InA() {
+				l.ALine = e.AStart + i + 1
+			}
+			if l.InB() {
+				l.BLine = e.BStart + i + 1
+			}
+			lines = append(lines, l)
+		}
+	}
+	postProcess(lines, func(l, m Line, inA bool) bool {
+		if inA {
+			return a[l.ALine-1] == a[m.ALine-1]
+		}
+		return b[l.BLine-1] == b[m.BLine-1]
+	})
+	*s = script{}
+	for _, l := range lines {
+		s.add(l.Source, 1)
+	}
+}
+
+// Edits returns the lines of the solution as an edit script, where
+// consecutive lines with the same LineSource are merged into one Edit.
+func (d *DiffSolution) Edits() []Edit {
+	s := &script{}
+	for _, l := range d.TypedLines() {
+		s.add(l.Source, 1)
+	}
+	return s.edits
+}
+
+// coarseScript returns an edit script of a and b which only matches their
+// common prefix and suffix.
+func coarseScript(a, b []int) *script {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-suffix-1] == b[len(b)-suffix-1] {
+		suffix++
+	}
+	s := &script{}
+	s.add(LineFromBoth, prefix)
+	s.addRest(len(a)-suffix, len(b)-suffix)
+	s.add(LineFromBoth, suffix)
+	return s
+}
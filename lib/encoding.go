@@ -0,0 +1,229 @@
+package delta
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of a file.
+type Encoding string
+
+// These are the encodings detected by DecodeText.
+const (
+	EncodingUTF8    Encoding = "utf-8"
+	EncodingUTF16LE Encoding = "utf-16le"
+	EncodingUTF16BE Encoding = "utf-16be"
+	EncodingLatin1  Encoding = "latin-1"
+)
+
+// LineEnding describes the line endings of a file.
+type LineEnding string
+
+// These are valid values for LineEnding.
+const (
+	LineEndingNone  LineEnding = ""      // the file has no line breaks
+	LineEndingLF    LineEnding = "LF"    // lines end with \n
+	LineEndingCRLF  LineEnding = "CRLF"  // lines end with \r\n
+	LineEndingMixed LineEnding = "mixed" // both LF and CRLF are used
+)
+
+// Text is the decoded contents of a file.
+type Text struct {
+	// Content is the text as UTF-8, without the byte order mark. Line
+	// endings are unchanged, so lines split on \n may end with \r.
+	Content    string
+	Encoding   Encoding
+	BOM        bool // whether the file starts with a byte order mark
+	LineEnding LineEnding
+}
+
+var boms = []struct {
+	encoding Encoding
+	bom      string
+}{
+	{EncodingUTF8, "\xef\xbb\xbf"},
+	{EncodingUTF16LE, "\xff\xfe"},
+	{EncodingUTF16BE, "\xfe\xff"},
+}
+
+// DecodeText decodes the contents of a file. The encoding is detected from
+// the byte order mark if there is one. Otherwise UTF-16 is detected from
+// the NUL bytes of ASCII characters, valid UTF-8 is used as is, and other
+// data is decoded as Latin-1, which never fails. A trailing odd byte of
+// UTF-16 is decoded as U+FFFD.
+func DecodeText(data []byte) Text {
+	t := Text{Encoding: detectEncoding(data)}
+	for _, b := range boms {
+		if b.encoding == t.Encoding && strings.HasPrefix(string(data), b.bom) {
+			t.BOM = true
+			data = data[len(b.bom):]
+		}
+	}
+
+	switch t.Encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		u := make([]uint16, len(data)/2)
+		for i := range u {
+			lo, hi := data[2*i], data[2*i+1]
+			if t.Encoding == EncodingUTF16BE {
+				lo, hi = hi, lo
+			}
+			u[i] = uint16(lo) | uint16(hi)<<8
+		}
+		t.Content = string(utf16.Decode(u))
+		if len(data)%2 == 1 {
+			// a trailing half of a code unit is kept as an invalid character
+			t.Content += string(utf8.RuneError)
+		}
+	case EncodingLatin1:
+		r := make([]rune, len(data))
+		for i, b := range data {
+			r[i] = rune(b)
+		}
+		t.Content = string(r)
+	default:
+		t.Content = string(data)
+	}
+	t.LineEnding = detectLineEnding(t.Content)
+	return t
+}
+
+func detectEncoding(data []byte) Encoding {
+	for _, b := range boms {
+		if strings.HasPrefix(string(data), b.bom) {
+			return b.encoding
+		}
+	}
+	if len(data) > 0 && len(data)%2 == 0 {
+		// count the NUL bytes at even and odd offsets; ASCII text encoded
+		// as UTF-16 has NUL bytes at one of them but not the other.
+		zeros := [2]int{}
+		for i, b := range data {
+			if b == 0 {
+				zeros[i%2]++
+			}
+		}
+		pairs := len(data) / 2
+		switch {
+		case zeros[1] > pairs/2 && zeros[0] == 0:
+			return EncodingUTF16LE
+		case zeros[0] > pairs/2 && zeros[1] == 0:
+			return EncodingUTF16BE
+		}
+	}
+	if utf8.Valid(data) {
+		return EncodingUTF8
+	}
+	return EncodingLatin1
+}
+
+func detectLineEnding(s string) LineEnding {
+	lf := strings.Count(s, "\n")
+	crlf := strings.Count(s, "\r\n")
+	switch {
+	case lf == 0:
+		return LineEndingNone
+	case crlf == 0:
+		return LineEndingLF
+	case crlf == lf:
+		return LineEndingCRLF
+	}
+	return LineEndingMixed
+}
+
+// Encode encodes s. Characters which are not supported by the encoding
+// are replaced with '?'.
+func (e Encoding) Encode(s string) []byte {
+	switch e {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		u := utf16.Encode([]rune(s))
+		b := make([]byte, 0, 2*len(u))
+		for _, c := range u {
+			if e == EncodingUTF16BE {
+				b = append(b, byte(c>>8), byte(c))
+			} else {
+				b = append(b, byte(c), byte(c>>8))
+			}
+		}
+		return b
+	case EncodingLatin1:
+		b := make([]byte, 0, len(s))
+		for _, r := range s {
+			if r > 0xff {
+				r = '?'
+			}
+			b = append(b, byte(r))
+		}
+		return b
+	}
+	return []byte(s)
+}
+
+// BOM returns the byte order mark of the encoding, or nil if it has none.
+func (e Encoding) BOM() []byte {
+	for _, b := range boms {
+		if b.encoding == e {
+			return []byte(b.bom)
+		}
+	}
+	return nil
+}
+
+// Bytes encodes the text in its original encoding, including the byte
+// order mark.
+func (t Text) Bytes() []byte {
+	b := t.Encoding.Encode(t.Content)
+	if t.BOM {
+		b = append(t.Encoding.BOM(), b...)
+	}
+	return b
+}
+
+// TextChanges describes the changes in encoding, byte order mark and line
+// endings between two files, e.g. "line endings changed from CRLF to LF".
+// These changes are not visible in a diff of the decoded contents.
+func TextChanges(from, to Text) []string {
+	changes := []string{}
+	// ASCII text is both valid UTF-8 and Latin-1, so it is only detected as
+	// UTF-8
+	asciiCompatible := func(e Encoding) bool { return e == EncodingUTF8 || e == EncodingLatin1 }
+	compatible := asciiCompatible(from.Encoding) && asciiCompatible(to.Encoding) &&
+		(isASCII(from.Content) || isASCII(to.Content))
+	if from.Encoding != to.Encoding && !compatible {
+		changes = append(changes, fmt.Sprintf("encoding changed from %s to %s", from.Encoding, to.Encoding))
+	}
+	if from.BOM && !to.BOM {
+		changes = append(changes, "byte order mark removed")
+	} else if !from.BOM && to.BOM {
+		changes = append(changes, "byte order mark added")
+	}
+	if from.LineEnding != to.LineEnding && from.LineEnding != LineEndingNone && to.LineEnding != LineEndingNone {
+		changes = append(changes, fmt.Sprintf("line endings changed from %s to %s", from.LineEnding, to.LineEnding))
+	}
+	return changes
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// LineEndingChange returns e.g. "CRLF → LF" if the line is matched, and
+// its A and B sides only differ by a carriage return at the end. Otherwise
+// it returns "".
+func (l Line) LineEndingChange() string {
+	if l.Source != LineFromBoth || l.A == l.B ||
+		strings.TrimSuffix(l.A, "\r") != strings.TrimSuffix(l.B, "\r") {
+		return ""
+	}
+	if strings.HasSuffix(l.A, "\r") {
+		return "CRLF → LF"
+	}
+	return "LF → CRLF"
+}
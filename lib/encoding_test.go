@@ -0,0 +1,65 @@
+package delta
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		data string
+		e    Text
+	}{
+		{"a\nb\n", Text{"a\nb\n", EncodingUTF8, false, LineEndingLF}},
+		{"\xef\xbb\xbfa\r\nb\r\n", Text{"a\r\nb\r\n", EncodingUTF8, true, LineEndingCRLF}},
+		{"\xff\xfea\x00\n\x00\xe9\x00", Text{"a\né", EncodingUTF16LE, true, LineEndingLF}},
+		{"\x00a\x00\r\x00\n\x00b", Text{"a\r\nb", EncodingUTF16BE, false, LineEndingCRLF}},
+		{"caf\xe9\r\nx\n", Text{"café\r\nx\n", EncodingLatin1, false, LineEndingMixed}},
+		{"", Text{"", EncodingUTF8, false, LineEndingNone}},
+	}
+	for _, tt := range tests {
+		d := DecodeText([]byte(tt.data))
+		if !reflect.DeepEqual(d, tt.e) {
+			t.Errorf("%q: expected %+v but got %+v", tt.data, tt.e, d)
+		}
+		if b := string(d.Bytes()); b != tt.data {
+			t.Errorf("%q: expected the original bytes but got %q", tt.data, b)
+		}
+	}
+}
+
+func TestTextChanges(t *testing.T) {
+	a := DecodeText([]byte("\xef\xbb\xbfa\r\nb\r\n"))
+	b := DecodeText([]byte("a\nb\n"))
+	e := []string{"byte order mark removed", "line endings changed from CRLF to LF"}
+	if c := TextChanges(a, b); !reflect.DeepEqual(c, e) {
+		t.Errorf("expected %q but got %q", e, c)
+	}
+
+	d, _ := Diff(a.Content, b.Content, WithWhitespace(WhitespaceOptions{IgnoreCRAtEOL: true}))
+	for _, l := range d.TypedLines()[:2] {
+		if c := l.LineEndingChange(); c != "CRLF → LF" {
+			t.Errorf("%q: unexpected line ending change %q", l.A, c)
+		}
+	}
+}
+
+func TestTextChangesASCII(t *testing.T) {
+	a := DecodeText([]byte("caf\xe9 x\n"))
+	b := DecodeText([]byte("cafe x\n"))
+	if c := TextChanges(a, b); len(c) != 0 {
+		t.Errorf("expected no changes between Latin-1 and ASCII but got %q", c)
+	}
+	c := DecodeText([]byte("caf\xc3\xa9 x\n"))
+	e := []string{"encoding changed from latin-1 to utf-8"}
+	if c := TextChanges(a, c); !reflect.DeepEqual(c, e) {
+		t.Errorf("expected %q but got %q", e, c)
+	}
+}
+
+func TestDecodeTextOddUTF16(t *testing.T) {
+	d := DecodeText([]byte("\xff\xfea\x00b"))
+	if d.Encoding != EncodingUTF16LE || d.Content != "a�" {
+		t.Errorf("expected the trailing byte to be kept but got %+v", d)
+	}
+}
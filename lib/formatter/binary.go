@@ -0,0 +1,33 @@
+package formatter
+
+import (
+	"bytes"
+
+	"github.com/octavore/delta/lib"
+)
+
+// BinaryHTML renders the summary of a binary diff for the delta GUI, with
+// the size and hash of each file in place of its diff pane.
+func BinaryHTML(b *delta.BinaryDiff) string {
+	verb := "differ"
+	if !b.Changed() {
+		verb = "are identical"
+	}
+	buf := bytes.NewBufferString("<div class='delta-binary'>\n")
+	must(div.Execute(buf, elem{"delta-binary-title", "Binary files " + verb}))
+	must(div.Execute(buf, elem{"delta-binary-file", b.From.String()}))
+	must(div.Execute(buf, elem{"delta-binary-file", b.To.String()}))
+	buf.WriteString("</div>")
+	return buf.String()
+}
+
+// BinaryUnified renders a binary diff like diff -u, which states that the
+// files differ, followed by their sizes and hashes as in Summary. The names
+// of the files are taken from opts, and an empty string is returned if the
+// files are identical.
+func BinaryUnified(b *delta.BinaryDiff, opts UnifiedOptions) string {
+	if !b.Changed() {
+		return ""
+	}
+	return b.Summary(opts.FromFile, opts.ToFile) + "\n"
+}
@@ -36,40 +36,54 @@ var svgClasses = map[delta.LineSource]string{
 func HTMLLine(d *delta.DiffSolution) (string, string) {
 	a := &bytes.Buffer{}
 	b := &bytes.Buffer{}
-	for _, word := range d.Lines {
-		switch delta.LineSource(word[2]) {
+	for _, word := range d.TypedLines() {
+		switch word.Source {
 		case delta.LineFromA:
-			span.Execute(a, elem{"w-add", word[0]})
+			span.Execute(a, elem{"w-add", word.A})
 			span.Execute(b, elem{"w-del", ""})
 		case delta.LineFromB:
 			span.Execute(a, elem{"w-del", ""})
-			span.Execute(b, elem{"w-add", word[1]})
+			span.Execute(b, elem{"w-add", word.B})
 		case delta.LineFromBothEdit:
-			span.Execute(a, elem{"w-edit", word[0]})
-			span.Execute(b, elem{"w-edit", word[1]})
+			span.Execute(a, elem{"w-edit", word.A})
+			span.Execute(b, elem{"w-edit", word.B})
 		case delta.LineFromBoth:
-			a.WriteString(template.HTMLEscapeString(word[0]))
-			b.WriteString(template.HTMLEscapeString(word[1]))
+			a.WriteString(template.HTMLEscapeString(word.A))
+			b.WriteString(template.HTMLEscapeString(word.B))
 		}
 	}
 	return a.String(), b.String()
 }
 
+// eolHTML renders a line without its carriage return, followed by the name
+// of its line ending.
+func eolHTML(line, eol string) template.HTML {
+	b := &bytes.Buffer{}
+	b.WriteString(template.HTMLEscapeString(strings.TrimSuffix(line, "\r")))
+	span.Execute(b, elem{"delta-eol", eol})
+	return template.HTML(b.String())
+}
+
 // HTML builds up a html diff. Here be dragons! This is meant for the delta GUI.
-func HTML(d *delta.DiffSolution) string {
+func HTML(d *delta.DiffSolution, opts ...Option) string {
+	return HTMLLines(d.TypedLines(), opts...)
+}
+
+// HTMLLines builds up a html diff from the given lines, using their line
+// numbers in the gutters. This allows rendering partial diffs, e.g. the
+// hunks of a patch, in which case the status of the file should be set with
+// WithFileStatus.
+func HTMLLines(lines []delta.Line, opts ...Option) string {
+	o := newOptions(opts)
+
 	// closest contains the number of lines to the *next* changed lines
 	maxContext := 10
 	maxContext++ // + 1 for lines to hide
-	nextChange := make([]int, len(d.Lines))
-	lastChangedLine := len(d.Lines) + 10
-	for i := len(d.Lines) - 1; i > -1; i-- {
-		switch delta.LineSource(d.Lines[i][2]) {
-		case delta.LineFromA, delta.LineFromB, delta.LineFromBothEdit:
+	nextChange := make([]int, len(lines))
+	lastChangedLine := len(lines) + 10
+	for i := len(lines) - 1; i > -1; i-- {
+		if lines[i].Changed() {
 			lastChangedLine = i
-		case delta.LineFromBoth:
-			if d.Lines[i][0] != d.Lines[i][1] {
-				lastChangedLine = i
-			}
 		}
 		nextChange[i] = lastChangedLine - i
 		if nextChange[i] > maxContext {
@@ -77,6 +91,8 @@ func HTML(d *delta.DiffSolution) string {
 		}
 	}
 
+	// li and ri count the lines of A and B so far, which may differ from
+	// their line numbers if lines is part of a diff
 	li, ri := 0, 0
 	lg := bytes.NewBufferString("<div id='gutter-left' class='gutter'>\n")
 	rg := bytes.NewBufferString("<div id='gutter-right' class='gutter'>\n")
@@ -86,13 +102,23 @@ func HTML(d *delta.DiffSolution) string {
 
 	lastSource := delta.LineFromBoth
 	lineHeight := 16
-	ll := bytes.NewBufferString(fmt.Sprintf(`<div><svg width="16" height="%d">`, lineHeight*len(d.Lines)))
+	ll := bytes.NewBufferString(fmt.Sprintf(`<div><svg width="16" height="%d">`, lineHeight*len(lines)))
 
-	for i, l := range d.Lines {
-		ls := delta.LineSource(l[2])
+	// the lines of the nth moved block have the class mv-n, so that the
+	// delta GUI can connect its positions in A and B
+	movedFrom, movedTo := map[int]string{}, map[int]string{}
+	for n, m := range delta.Moves(lines) {
+		for k := 0; k < m.Len; k++ {
+			movedFrom[m.AStart+k] = " mv-" + strconv.Itoa(n)
+			movedTo[m.BStart+k] = " mv-" + strconv.Itoa(n)
+		}
+	}
+
+	for i, l := range lines {
+		ls := l.Source
 		if ls != lastSource {
 			lastSource = ls
-			if l[0] != l[1] {
+			if l.A != l.B {
 				ll.WriteString(
 					fmt.Sprintf(`<line x1="%d" x2="%d" y1="%d" y2="%d" stroke-width="1" class="connector-%s" />`,
 						0, 16, lineHeight*li, lineHeight*ri, svgClasses[lastSource],
@@ -103,7 +129,7 @@ func HTML(d *delta.DiffSolution) string {
 
 		// closestChange keeps track of how close we are to the *previous* change.
 		closestChange := 0
-		if ls == delta.LineFromBoth && l[0] == l[1] {
+		if !l.Changed() {
 			closestChange = i - lastChangedLine
 			if closestChange > nextChange[i] {
 				closestChange = nextChange[i]
@@ -116,46 +142,74 @@ func HTML(d *delta.DiffSolution) string {
 		}
 		lc := "lc-" + strconv.Itoa(closestChange) + " line "
 		if ls == delta.LineFromA {
-			li++
-			must(div.Execute(lg, elem{lc + "la", li}))
+			must(div.Execute(lg, elem{lc + "la", l.ALine}))
 			must(div.Execute(rg, elem{lc, ""}))
-			must(div.Execute(lb, elem{lc + "la", l[0]}))
+			must(div.Execute(lb, elem{lc + "la", l.A}))
 			must(div.Execute(rb, elem{lc, ""}))
+		} else if ls == delta.LineMovedFrom {
+			must(div.Execute(lg, elem{lc + "la lmv" + movedFrom[l.ALine], l.ALine}))
+			must(div.Execute(rg, elem{lc, ""}))
+			must(div.Execute(lb, elem{lc + "la lmv" + movedFrom[l.ALine], l.A}))
+			must(div.Execute(rb, elem{lc, ""}))
+		} else if ls == delta.LineMovedTo {
+			must(div.Execute(lg, elem{lc, ""}))
+			must(div.Execute(rg, elem{lc + "la lmv" + movedTo[l.BLine], l.BLine}))
+			must(div.Execute(lb, elem{lc, ""}))
+			must(div.Execute(rb, elem{lc + "la lmv" + movedTo[l.BLine], l.B}))
 		} else if ls == delta.LineFromB {
-			ri++
 			must(div.Execute(lg, elem{lc, ""}))
-			must(div.Execute(rg, elem{lc + "la", ri}))
+			must(div.Execute(rg, elem{lc + "la", l.BLine}))
+			must(div.Execute(lb, elem{lc, ""}))
+			must(div.Execute(rb, elem{lc + "la", l.B}))
+		} else if ls == delta.LineIgnoredFromA {
+			must(div.Execute(lg, elem{lc + "lm", l.ALine}))
+			must(div.Execute(rg, elem{lc, ""}))
+			must(div.Execute(lb, elem{lc + "lm", l.A}))
+			must(div.Execute(rb, elem{lc, ""}))
+		} else if ls == delta.LineIgnoredFromB {
+			must(div.Execute(lg, elem{lc, ""}))
+			must(div.Execute(rg, elem{lc + "lm", l.BLine}))
 			must(div.Execute(lb, elem{lc, ""}))
-			must(div.Execute(rb, elem{lc + "la", l[1]}))
+			must(div.Execute(rb, elem{lc + "lm", l.B}))
 		} else if ls == delta.LineFromBothEdit {
-			li++
-			ri++
 			dl, dr := "", ""
-			sol := delta.DiffLine(l[0], l[1])
+			sol := o.diffLine(l.A, l.B)
 			if sol != nil {
 				dl, dr = HTMLLine(sol)
 			} else {
-				dl = template.HTMLEscapeString(l[0])
-				dr = template.HTMLEscapeString(l[1])
+				dl = template.HTMLEscapeString(l.A)
+				dr = template.HTMLEscapeString(l.B)
 			}
-			must(div.Execute(lg, elem{lc + "ln", li}))
-			must(div.Execute(rg, elem{lc + "ln", ri}))
+			must(div.Execute(lg, elem{lc + "ln", l.ALine}))
+			must(div.Execute(rg, elem{lc + "ln", l.BLine}))
 			must(div.Execute(lb, elem{lc + "ln", template.HTML(dl)}))
 			must(div.Execute(rb, elem{lc + "ln", template.HTML(dr)}))
-		} else if l[0] != l[1] {
-			li++
-			ri++
-			must(div.Execute(lg, elem{lc + "line-ws", li}))
-			must(div.Execute(rg, elem{lc + "line-ws", ri}))
-			must(div.Execute(lb, elem{lc + "line-ws", l[0]}))
-			must(div.Execute(rb, elem{lc + "line-ws", l[1]}))
+		} else if eol := l.LineEndingChange(); eol != "" {
+			// the line ending of each side is shown after the line
+			ea, eb := "LF", "CRLF"
+			if strings.HasSuffix(l.A, "\r") {
+				ea, eb = eb, ea
+			}
+			must(div.Execute(lg, elem{lc + "line-ws line-eol", l.ALine}))
+			must(div.Execute(rg, elem{lc + "line-ws line-eol", l.BLine}))
+			must(div.Execute(lb, elem{lc + "line-ws line-eol", eolHTML(l.A, ea)}))
+			must(div.Execute(rb, elem{lc + "line-ws line-eol", eolHTML(l.B, eb)}))
+		} else if l.A != l.B {
+			must(div.Execute(lg, elem{lc + "line-ws", l.ALine}))
+			must(div.Execute(rg, elem{lc + "line-ws", l.BLine}))
+			must(div.Execute(lb, elem{lc + "line-ws", l.A}))
+			must(div.Execute(rb, elem{lc + "line-ws", l.B}))
 		} else if ls == delta.LineFromBoth {
+			must(div.Execute(lg, elem{lc + "lm", l.ALine}))
+			must(div.Execute(rg, elem{lc + "lm", l.BLine}))
+			must(div.Execute(lb, elem{lc + "lm", l.A}))
+			must(div.Execute(rb, elem{lc + "lm", l.B}))
+		}
+		if l.InA() {
 			li++
+		}
+		if l.InB() {
 			ri++
-			must(div.Execute(lg, elem{lc + "lm", li}))
-			must(div.Execute(rg, elem{lc + "lm", ri}))
-			must(div.Execute(lb, elem{lc + "lm", l[0]}))
-			must(div.Execute(rb, elem{lc + "lm", l[1]}))
 		}
 	}
 
@@ -166,10 +220,22 @@ func HTML(d *delta.DiffSolution) string {
 	ll.WriteString("</div>")
 	lbs := strings.Replace(lb.String(), "\t", "<span class='delta-tab'>\t</span>", -1)
 	rbs := strings.Replace(rb.String(), "\t", "<span class='delta-tab'>\t</span>", -1)
-	if li == 0 {
-		return rg.String() + rbs
+	if o.status == "" {
+		// an empty file is a single empty line, which matches the trailing
+		// empty line of the other file
+		last := len(lines) - 1
+		empty := last >= 0 && lines[last].Source == delta.LineFromBoth &&
+			lines[last].A == "" && lines[last].B == ""
+		if li == 0 || li == 1 && empty {
+			o.status = delta.FileAdded
+		} else if ri == 0 || ri == 1 && empty {
+			o.status = delta.FileDeleted
+		}
 	}
-	if ri == 0 {
+	switch o.status {
+	case delta.FileAdded:
+		return rg.String() + rbs
+	case delta.FileDeleted:
 		return lg.String() + lbs
 	}
 	return lg.String() + lbs + rg.String() + rbs
@@ -0,0 +1,57 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/octavore/delta/lib"
+)
+
+func TestHTMLLinesFileStatus(t *testing.T) {
+	// a hunk of a modified file which only adds lines, as in git diff -U0
+	lines := []delta.Line{
+		{Source: delta.LineFromB, B: "one", BLine: 5},
+		{Source: delta.LineFromB, B: "two", BLine: 6},
+	}
+	tests := []struct {
+		opts        []Option
+		left, right bool
+	}{
+		{nil, false, true},
+		{[]Option{WithFileStatus(delta.FileAdded)}, false, true},
+		{[]Option{WithFileStatus(delta.FileModified)}, true, true},
+		{[]Option{WithFileStatus(delta.FileDeleted)}, true, false},
+	}
+	for i, tt := range tests {
+		h := HTMLLines(lines, tt.opts...)
+		if l := strings.Contains(h, "diff-left"); l != tt.left {
+			t.Errorf("%d: expected left pane %v but got %v", i, tt.left, l)
+		}
+		if r := strings.Contains(h, "diff-right"); r != tt.right {
+			t.Errorf("%d: expected right pane %v but got %v", i, tt.right, r)
+		}
+	}
+
+	// the empty old version of an added file is a single empty line
+	h := HTML(delta.NewDiffSolution([]delta.Line{
+		{Source: delta.LineFromB, B: "one", BLine: 1},
+		{Source: delta.LineFromBoth, ALine: 1, BLine: 2},
+	}))
+	if strings.Contains(h, "diff-left") || !strings.Contains(h, "diff-right") {
+		t.Errorf("expected only the right pane for an added file")
+	}
+}
+
+func TestHTMLMoves(t *testing.T) {
+	a := "func a() {\n  return someValue + 1\n}\n\nfunc b() {\n  return 2\n}\n"
+	b := "func b() {\n  return 2\n}\n\nfunc a() {\n  return someValue + 1\n}\n"
+	d := delta.HistogramDiff(a, b)
+	d.DetectMoves()
+	h := HTML(d)
+
+	// the moved block is marked in both panes, so that it can be connected
+	panes := strings.Split(h, "<div id='gutter-right'")
+	if len(panes) != 2 || !strings.Contains(panes[0], "lmv mv-0") || !strings.Contains(panes[1], "lmv mv-0") {
+		t.Errorf("expected the moved block in both panes:\n%s", h)
+	}
+}
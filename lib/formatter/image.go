@@ -0,0 +1,129 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	_ "image/jpeg" // register the JPEG decoder for image.Decode
+	"image/png"
+
+	"github.com/octavore/delta/lib"
+)
+
+const imageTmpl = `<div class='delta-image delta-image-mode-side'>
+<div class='delta-image-modes'>
+{{- range .Modes}}<span class='delta-image-mode' data-mode='{{.Name}}'>{{.Title}}</span>{{end -}}
+<input class='delta-image-slider' type='range' min='0' max='100' value='50'>
+</div>
+<div class='delta-image-view'>
+{{- range .Panes}}
+<div class='delta-image-pane {{.Classes}}'>
+{{- if .Src}}<img src='{{.Src}}'>{{end -}}
+<div class='delta-image-info'>{{.Info}}</div>
+</div>
+{{- end}}
+</div>
+</div>
+`
+
+var imageView = template.Must(template.New("image").Parse(imageTmpl))
+
+// maxDiffPixels is the largest number of pixels of the difference mask of
+// two images, since the images are decoded in memory to compare them.
+const maxDiffPixels = 1 << 24
+
+type imageMode struct {
+	Name, Title string
+}
+
+type imagePane struct {
+	Classes string
+	Src     template.URL
+	Info    string
+}
+
+// ImageHTML renders two versions of an image for the delta GUI, where
+// either may be empty if the file was added or deleted. The images are
+// embedded as data URIs, and can be viewed side by side, or overlaid in
+// swipe and onion skin modes. If both are PNG or JPEG images, a mask of
+// the changed pixels is also shown in the difference mode, see
+// delta.DiffImages, unless it would have more than maxDiffPixels pixels.
+// The modes are switched by the delta GUI.
+//
+// If d is not nil, e.g. the diff of the source of SVG images, it is
+// rendered below the images as by HTML.
+func ImageHTML(a, b []byte, d *delta.DiffSolution, opts ...Option) string {
+	modes := []imageMode{{"side", "Side by side"}}
+	panes := []imagePane{imageFilePane("delta-image-from", a), imageFilePane("delta-image-to", b)}
+	if panes[0].Src != "" && panes[1].Src != "" {
+		modes = append(modes, imageMode{"swipe", "Swipe"}, imageMode{"onion", "Onion skin"})
+	}
+	if ia, ib, ok := decodeImages(a, b); ok {
+		id := delta.DiffImages(ia, ib)
+		mask := &bytes.Buffer{}
+		must(png.Encode(mask, id.Mask))
+		size := id.Mask.Bounds().Size()
+		modes = append(modes, imageMode{"difference", "Difference"})
+		panes = append(panes, imagePane{
+			Classes: "delta-image-difference",
+			Src:     dataURI("image/png", mask.Bytes()),
+			Info:    fmt.Sprintf("%d of %d pixels changed", id.Changed, size.X*size.Y),
+		})
+	}
+
+	buf := &bytes.Buffer{}
+	must(imageView.Execute(buf, map[string]interface{}{"Modes": modes, "Panes": panes}))
+	if d == nil {
+		return buf.String()
+	}
+	return "<div class='delta-image-diff'>\n" + buf.String() +
+		"<div class='delta-image-text'>\n" + HTML(d, opts...) + "</div>\n</div>"
+}
+
+// decodeImages decodes a and b if both are PNG or JPEG images, and their
+// difference mask has at most maxDiffPixels pixels. Their sizes are read
+// from their headers first, so that large images are not decoded.
+func decodeImages(a, b []byte) (image.Image, image.Image, bool) {
+	ca, _, errA := image.DecodeConfig(bytes.NewReader(a))
+	cb, _, errB := image.DecodeConfig(bytes.NewReader(b))
+	if errA != nil || errB != nil {
+		return nil, nil, false
+	}
+	w, h := ca.Width, ca.Height
+	if cb.Width > w {
+		w = cb.Width
+	}
+	if cb.Height > h {
+		h = cb.Height
+	}
+	if w > maxDiffPixels || h > maxDiffPixels || w*h > maxDiffPixels {
+		return nil, nil, false
+	}
+	ia, _, errA := image.Decode(bytes.NewReader(a))
+	ib, _, errB := image.Decode(bytes.NewReader(b))
+	return ia, ib, errA == nil && errB == nil
+}
+
+// imageFilePane returns the pane showing the image data. Its source is
+// empty if data is not an image.
+func imageFilePane(classes string, data []byte) imagePane {
+	p := imagePane{Classes: classes, Info: fmt.Sprintf("%d bytes", len(data))}
+	typ := delta.ImageType(data)
+	if typ == "" {
+		if len(data) > 0 {
+			p.Info = "not an image, " + p.Info
+		}
+		return p
+	}
+	p.Src = dataURI(typ, data)
+	if c, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		p.Info = fmt.Sprintf("%d × %d, %s", c.Width, c.Height, p.Info)
+	}
+	return p
+}
+
+func dataURI(typ string, data []byte) template.URL {
+	return template.URL("data:" + typ + ";base64," + base64.StdEncoding.EncodeToString(data))
+}
@@ -0,0 +1,88 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/octavore/delta/lib"
+)
+
+func TestImageHTML(t *testing.T) {
+	encode := func(w, h int) []byte {
+		buf := &bytes.Buffer{}
+		must(png.Encode(buf, image.NewNRGBA(image.Rect(0, 0, w, h))))
+		return buf.Bytes()
+	}
+	// large is the header of a PNG image which claims to be 50000 × 50000
+	large := []byte("\x89PNG\r\n\x1a\n")
+	ihdr := []byte("IHDR\x00\x00\xc3\x50\x00\x00\xc3\x50\x08\x06\x00\x00\x00")
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(ihdr))
+	large = append(append(append(large, 0, 0, 0, 13), ihdr...), crc...)
+	svgA := []byte("<svg width='1'/>\n")
+	svgB := []byte("<svg width='2'/>\n")
+	tests := []struct {
+		name     string
+		a, b     []byte
+		d        *delta.DiffSolution
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "png",
+			a:    encode(2, 2),
+			b:    encode(3, 2),
+			contains: []string{
+				"data-mode='side'", "data-mode='swipe'", "data-mode='onion'", "data-mode='difference'",
+				"delta-image-difference", "2 of 6 pixels changed", "2 × 2, ", "3 × 2, ",
+			},
+			excludes: []string{"delta-image-text"},
+		},
+		{
+			name:     "svg",
+			a:        svgA,
+			b:        svgB,
+			d:        delta.HistogramDiff(string(svgA), string(svgB)),
+			contains: []string{"data-mode='swipe'", "data:image/svg&#43;xml;base64,", "delta-image-text", "diff-left"},
+			excludes: []string{"data-mode='difference'", "delta-image-difference"},
+		},
+		{
+			name:     "too large",
+			a:        large,
+			b:        encode(1, 1),
+			contains: []string{"data-mode='swipe'", "50000 × 50000, "},
+			excludes: []string{"data-mode='difference'", "delta-image-difference"},
+		},
+		{
+			name:     "added",
+			b:        encode(1, 1),
+			contains: []string{"data-mode='side'", "0 bytes"},
+			excludes: []string{"data-mode='swipe'", "data-mode='difference'"},
+		},
+		{
+			name:     "not an image",
+			a:        []byte("text"),
+			b:        encode(1, 1),
+			contains: []string{"not an image, 4 bytes"},
+			excludes: []string{"data-mode='swipe'", "data-mode='difference'"},
+		},
+	}
+	for _, tt := range tests {
+		h := ImageHTML(tt.a, tt.b, tt.d)
+		for _, s := range tt.contains {
+			if !strings.Contains(h, s) {
+				t.Errorf("%s: expected %q in:\n%s", tt.name, s, h)
+			}
+		}
+		for _, s := range tt.excludes {
+			if strings.Contains(h, s) {
+				t.Errorf("%s: unexpected %q in:\n%s", tt.name, s, h)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package formatter
+
+import (
+	"context"
+
+	"github.com/octavore/delta/lib"
+)
+
+// Option configures how HTML, ColoredText and ColoredPatch highlight the
+// changes within edited lines.
+type Option func(*options)
+
+type options struct {
+	granularity delta.Granularity
+	cleanup     float64
+	status      delta.FileStatus
+	ctx         context.Context
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		granularity: delta.GranularityWord,
+		cleanup:     delta.DefaultCleanup,
+		ctx:         context.Background(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithGranularity sets the granularity of intra-line diffs. The default is
+// delta.GranularityWord.
+func WithGranularity(g delta.Granularity) Option {
+	return func(o *options) { o.granularity = g }
+}
+
+// WithCleanup sets the aggressiveness of the semantic cleanup of intra-line
+// diffs, see delta.DiffSolution.CleanupSemantic. The default is
+// delta.DefaultCleanup, and 0 disables the cleanup.
+func WithCleanup(aggressiveness float64) Option {
+	return func(o *options) { o.cleanup = aggressiveness }
+}
+
+// WithFileStatus sets the status of the file rendered by HTMLLines: only
+// the new file is shown if it was added, and only the old file if it was
+// deleted. By default, a file is taken to be added or deleted if it has no
+// lines, which is only correct if all its lines are rendered, as by HTML.
+func WithFileStatus(s delta.FileStatus) Option {
+	return func(o *options) { o.status = s }
+}
+
+// WithContext sets a context which stops intra-line diffs when it is done,
+// e.g. after a timeout. Edited lines are then highlighted as a whole, see
+// delta.DiffLineContext.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) { o.ctx = ctx }
+}
+
+// diffLine diffs the edited lines a and b.
+func (o *options) diffLine(a, b string) *delta.DiffSolution {
+	d, _ := delta.DiffLineContext(o.ctx, a, b, o.granularity)
+	if o.cleanup > 0 {
+		d.CleanupSemantic(o.cleanup)
+	}
+	return d
+}
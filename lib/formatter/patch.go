@@ -0,0 +1,162 @@
+package formatter
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/octavore/delta/lib"
+)
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiRed       = "\x1b[31m"
+	ansiGreen     = "\x1b[32m"
+	ansiYellow    = "\x1b[33m"
+	ansiCyan      = "\x1b[36m"
+	ansiHighlight = "\x1b[7m"
+)
+
+var patchHeaders = []string{
+	"diff ", "index ", "--- ", "+++ ", "old mode ", "new mode ",
+	"deleted file mode ", "new file mode ", "similarity index ",
+	"dissimilarity index ", "rename from ", "rename to ", "copy from ",
+	"copy to ", "Binary files ",
+}
+
+// ColoredPatch colors a patch, e.g. the output of git diff, for display in a
+// terminal. Changed words (or characters, see WithGranularity) are
+// highlighted in deleted lines which are followed by the same number of
+// added lines. Each line of the output corresponds to the same line of the
+// input, and text which is not part of a diff is unchanged, so this can be
+// used as git's core.pager or interactive.diffFilter. See PatchColorer to
+// color a patch as it is read.
+func ColoredPatch(patch string, opts ...Option) string {
+	buf := &bytes.Buffer{}
+	c := NewPatchColorer(buf, opts...)
+	for _, l := range strings.Split(strings.TrimSuffix(patch, "\n"), "\n") {
+		must(c.WriteLine(l))
+	}
+	must(c.Flush())
+	if !strings.HasSuffix(patch, "\n") {
+		buf.Truncate(buf.Len() - 1)
+	}
+	return buf.String()
+}
+
+// PatchColorer colors a patch line by line, like ColoredPatch. Deleted and
+// added lines are held until the end of the block of changes, so that they
+// can be compared; all other lines are written immediately.
+type PatchColorer struct {
+	w          io.Writer
+	o          *options
+	aRemaining int // lines of A remaining in the current hunk
+	bRemaining int // lines of B remaining in the current hunk
+	dels, adds []string
+}
+
+// NewPatchColorer returns a PatchColorer which writes to w.
+func NewPatchColorer(w io.Writer, opts ...Option) *PatchColorer {
+	return &PatchColorer{w: w, o: newOptions(opts)}
+}
+
+// WriteLine colors the next line of the patch, without its newline, and
+// writes it to w followed by a newline.
+func (c *PatchColorer) WriteLine(l string) error {
+	inHunk := c.aRemaining > 0 || c.bRemaining > 0
+	switch {
+	case inHunk && strings.HasPrefix(l, "-"):
+		c.dels = append(c.dels, l[1:])
+		c.aRemaining--
+		return c.flushHunk()
+	case inHunk && strings.HasPrefix(l, "+"):
+		c.adds = append(c.adds, l[1:])
+		c.bRemaining--
+		return c.flushHunk()
+	}
+
+	if err := c.Flush(); err != nil {
+		return err
+	}
+	switch {
+	case inHunk && (l == "" || l[0] == ' '):
+		c.aRemaining--
+		c.bRemaining--
+	case strings.HasPrefix(l, "@@"):
+		if _, aLen, _, bLen, ok := delta.ParseHunkHeader(l); ok {
+			c.aRemaining, c.bRemaining = aLen, bLen
+		}
+		l = ansiCyan + l + ansiReset
+	case strings.HasPrefix(l, "commit "):
+		l = ansiYellow + l + ansiReset
+	case isPatchHeader(l):
+		l = ansiBold + l + ansiReset
+	}
+	_, err := io.WriteString(c.w, l+"\n")
+	return err
+}
+
+// flushHunk flushes the held lines if they are the end of the hunk.
+func (c *PatchColorer) flushHunk() error {
+	if c.aRemaining > 0 || c.bRemaining > 0 {
+		return nil
+	}
+	return c.Flush()
+}
+
+// Flush writes the deleted and added lines which are held, highlighting
+// the changes between them. It is called by WriteLine at the end of each
+// block of changes, and must be called after the last line.
+func (c *PatchColorer) Flush() error {
+	hls := append([]string{}, c.dels...)
+	hrs := append([]string{}, c.adds...)
+	if len(c.dels) == len(c.adds) {
+		for i := range c.dels {
+			if sol := c.o.diffLine(c.dels[i], c.adds[i]); sol != nil {
+				hls[i], hrs[i] = coloredLine(sol, ansiRed, ansiGreen)
+			}
+		}
+	}
+	c.dels, c.adds = c.dels[:0], c.adds[:0]
+	buf := &bytes.Buffer{}
+	for _, l := range hls {
+		buf.WriteString(ansiRed + "-" + l + ansiReset + "\n")
+	}
+	for _, l := range hrs {
+		buf.WriteString(ansiGreen + "+" + l + ansiReset + "\n")
+	}
+	_, err := buf.WriteTo(c.w)
+	return err
+}
+
+func isPatchHeader(l string) bool {
+	for _, h := range patchHeaders {
+		if strings.HasPrefix(l, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// coloredLine renders a word diff into a before and after string, in the
+// given colors, with changed words highlighted.
+func coloredLine(d *delta.DiffSolution, colorA, colorB string) (string, string) {
+	a := &bytes.Buffer{}
+	b := &bytes.Buffer{}
+	for _, word := range d.TypedLines() {
+		switch word.Source {
+		case delta.LineFromA:
+			a.WriteString(ansiHighlight + word.A + ansiReset + colorA)
+		case delta.LineFromB:
+			b.WriteString(ansiHighlight + word.B + ansiReset + colorB)
+		case delta.LineFromBothEdit:
+			a.WriteString(ansiHighlight + word.A + ansiReset + colorA)
+			b.WriteString(ansiHighlight + word.B + ansiReset + colorB)
+		case delta.LineFromBoth:
+			a.WriteString(word.A)
+			b.WriteString(word.B)
+		}
+	}
+	return a.String(), b.String()
+}
@@ -0,0 +1,119 @@
+package formatter
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/octavore/delta/lib"
+)
+
+// ansiEscape matches the color codes added by ColoredPatch.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestColoredPatch(t *testing.T) {
+	patch := `commit 1234
+diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ one
+-two three
++two four
+ five
+`
+	e := "\x1b[33mcommit 1234\x1b[0m\n" +
+		"\x1b[1mdiff --git a/a.txt b/a.txt\x1b[0m\n" +
+		"\x1b[1m--- a/a.txt\x1b[0m\n" +
+		"\x1b[1m+++ b/a.txt\x1b[0m\n" +
+		"\x1b[36m@@ -1,3 +1,3 @@\x1b[0m\n" +
+		" one\n" +
+		"\x1b[31m-two \x1b[7mthree\x1b[0m\x1b[31m\x1b[0m\n" +
+		"\x1b[32m+two \x1b[7mfour\x1b[0m\x1b[32m\x1b[0m\n" +
+		" five\n"
+	c := ColoredPatch(patch)
+	if c != e {
+		t.Errorf("expected:\n%q\nbut got:\n%q", e, c)
+	}
+	if strings.Count(c, "\n") != strings.Count(patch, "\n") {
+		t.Errorf("expected output to have the same number of lines as the input")
+	}
+}
+
+func TestColoredPatchGranularity(t *testing.T) {
+	patch := "@@ -1 +1 @@\n-getValue\n+getValues\n"
+	e := "\x1b[36m@@ -1 +1 @@\x1b[0m\n" +
+		"\x1b[31m-getValue\x1b[0m\n" +
+		"\x1b[32m+getValue\x1b[7ms\x1b[0m\x1b[32m\x1b[0m\n"
+	if c := ColoredPatch(patch, WithGranularity(delta.GranularityChar)); c != e {
+		t.Errorf("expected:\n%q\nbut got:\n%q", e, c)
+	}
+
+	// Latin-1 text is not valid UTF-8, and is split into bytes
+	patch = "@@ -1 +1 @@\n-caf\xe9\n+cafe\n"
+	e = "\x1b[36m@@ -1 +1 @@\x1b[0m\n" +
+		"\x1b[31m-caf\x1b[7m\xe9\x1b[0m\x1b[31m\x1b[0m\n" +
+		"\x1b[32m+caf\x1b[7me\x1b[0m\x1b[32m\x1b[0m\n"
+	for _, g := range []delta.Granularity{delta.GranularityChar, delta.GranularityGrapheme} {
+		if c := ColoredPatch(patch, WithGranularity(g)); c != e {
+			t.Errorf("%s: expected:\n%q\nbut got:\n%q", g, e, c)
+		}
+	}
+
+	// the text is unchanged at any granularity
+	patch = "@@ -1 +1 @@\n-caf\xe9 au lait\n+cafe au lait\n"
+	for _, g := range []delta.Granularity{delta.GranularityWord, delta.GranularityChar, delta.GranularityGrapheme} {
+		c := ColoredPatch(patch, WithGranularity(g))
+		if text := ansiEscape.ReplaceAllString(c, ""); text != patch {
+			t.Errorf("%s: expected the text:\n%q\nbut got:\n%q", g, patch, text)
+		}
+	}
+}
+
+func TestColoredPatchContext(t *testing.T) {
+	// once the context is done, edited lines are highlighted as a whole
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	patch := "@@ -1 +1 @@\n-two three\n+two four\n"
+	e := "\x1b[36m@@ -1 +1 @@\x1b[0m\n" +
+		"\x1b[31m-\x1b[7mtwo three\x1b[0m\x1b[31m\x1b[0m\n" +
+		"\x1b[32m+\x1b[7mtwo four\x1b[0m\x1b[32m\x1b[0m\n"
+	if c := ColoredPatch(patch, WithContext(ctx)); c != e {
+		t.Errorf("expected:\n%q\nbut got:\n%q", e, c)
+	}
+}
+
+func TestPatchColorer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	c := NewPatchColorer(buf)
+	for _, l := range []string{"@@ -1,3 +1,3 @@", " one", "-two"} {
+		if err := c.WriteLine(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the deleted line is held until the end of the block of changes
+	if e := "\x1b[36m@@ -1,3 +1,3 @@\x1b[0m\n one\n"; buf.String() != e {
+		t.Errorf("expected:\n%q\nbut got:\n%q", e, buf.String())
+	}
+	for _, l := range []string{"+three", " four"} {
+		if err := c.WriteLine(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !strings.HasSuffix(buf.String(), "\x1b[32m+\x1b[7mthree\x1b[0m\x1b[32m\x1b[0m\n four\n") {
+		t.Errorf("expected the changed lines to be written, got:\n%q", buf.String())
+	}
+
+	// the end of the hunk flushes the held lines
+	buf.Reset()
+	for _, l := range []string{"@@ -1 +1 @@", "-a", "+b"} {
+		if err := c.WriteLine(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !strings.HasSuffix(buf.String(), "\x1b[32m+\x1b[7mb\x1b[0m\x1b[32m\x1b[0m\n") {
+		t.Errorf("expected the hunk to be written, got:\n%q", buf.String())
+	}
+}
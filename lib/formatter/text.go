@@ -3,22 +3,44 @@ package formatter
 import (
 	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/octavore/delta/lib"
 )
 
-func ColoredText(d *delta.DiffSolution) string {
+// ColoredText renders a diff solution for display in a terminal. Changes
+// within edited lines are highlighted, see WithGranularity.
+func ColoredText(d *delta.DiffSolution, opts ...Option) string {
+	o := newOptions(opts)
 	buf := &bytes.Buffer{}
-	for _, l := range d.Lines {
-		if l[2] == "=" && l[0] == l[1] {
-			fmt.Fprintf(buf, " %s \n", l[0])
+	for _, l := range d.TypedLines() {
+		if eol := l.LineEndingChange(); eol != "" {
+			fmt.Fprintf(buf, " %s \x1b[2m(%s)\x1b[0m\n", strings.TrimSuffix(l.A, "\r"), eol)
 			continue
 		}
-		if l[0] != "" {
-			fmt.Fprintf(buf, "\x1b[31m-%s\x1b[0m\n", l[0])
+		if !l.Changed() {
+			fmt.Fprintf(buf, " %s \n", l.A)
+			continue
+		}
+		// moved lines are colored like git's --color-moved
+		switch l.Source {
+		case delta.LineMovedFrom:
+			fmt.Fprintf(buf, "\x1b[1;35m-%s\x1b[0m\n", l.A)
+			continue
+		case delta.LineMovedTo:
+			fmt.Fprintf(buf, "\x1b[1;36m+%s\x1b[0m\n", l.B)
+			continue
+		case delta.LineFromBothEdit:
+			a, b := coloredLine(o.diffLine(l.A, l.B), ansiRed, ansiGreen)
+			fmt.Fprintf(buf, "%s-%s%s\n", ansiRed, a, ansiReset)
+			fmt.Fprintf(buf, "%s+%s%s\n", ansiGreen, b, ansiReset)
+			continue
+		}
+		if l.A != "" {
+			fmt.Fprintf(buf, "\x1b[31m-%s\x1b[0m\n", l.A)
 		}
-		if l[1] != "" {
-			fmt.Fprintf(buf, "\x1b[32m+%s\x1b[0m\n", l[1])
+		if l.B != "" {
+			fmt.Fprintf(buf, "\x1b[32m+%s\x1b[0m\n", l.B)
 		}
 	}
 	return buf.String()
@@ -26,16 +48,20 @@ func ColoredText(d *delta.DiffSolution) string {
 
 func Text(d *delta.DiffSolution) string {
 	buf := &bytes.Buffer{}
-	for _, l := range d.Lines {
-		if l[2] == "=" && l[0] == l[1] {
-			fmt.Fprintf(buf, " %s \n", l[0])
+	for _, l := range d.TypedLines() {
+		if eol := l.LineEndingChange(); eol != "" {
+			fmt.Fprintf(buf, " %s (%s)\n", strings.TrimSuffix(l.A, "\r"), eol)
+			continue
+		}
+		if !l.Changed() {
+			fmt.Fprintf(buf, " %s \n", l.A)
 			continue
 		}
-		if l[0] != "" {
-			fmt.Fprintf(buf, "-%s\n", l[0])
+		if l.A != "" {
+			fmt.Fprintf(buf, "-%s\n", l.A)
 		}
-		if l[1] != "" {
-			fmt.Fprintf(buf, "+%s\n", l[1])
+		if l.B != "" {
+			fmt.Fprintf(buf, "+%s\n", l.B)
 		}
 	}
 	return buf.String()
@@ -0,0 +1,173 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/octavore/delta/lib"
+)
+
+// DefaultContext is the default number of unchanged lines shown around
+// each change in unified output, matching diff -u.
+const DefaultContext = 3
+
+const noNewline = "\\ No newline at end of file"
+
+// UnifiedOptions configures the output of Unified.
+type UnifiedOptions struct {
+	FromFile string // name of the original file, shown in the --- header
+	ToFile   string // name of the new file, shown in the +++ header
+	Context  int    // number of lines of context around each change
+
+	// FromText and ToText are the decoded files, if they were loaded using
+	// delta.DecodeText. Lines are then written in the original encodings of
+	// the files, including byte order marks, so that the patch applies to
+	// the original files. UTF-16 is written as UTF-8, since patch tools do
+	// not support it.
+	FromText, ToText *delta.Text
+}
+
+// Unified renders a diff solution in the unified diff format, which can be
+// applied using patch(1) or git apply. An empty string is returned if there
+// are no changes.
+func Unified(d *delta.DiffSolution, opts UnifiedOptions) string {
+	if hasBOM(opts.FromText) != hasBOM(opts.ToText) {
+		d = splitFirstLine(d)
+	}
+	d, aNoNewline, bNoNewline := trimNewlines(d)
+	aCount, bCount := 0, 0
+	for _, l := range d.TypedLines() {
+		if l.InA() {
+			aCount = l.ALine
+		}
+		if l.InB() {
+			bCount = l.BLine
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	writeLine := func(prefix, s string, noNewlineMarker bool) {
+		fmt.Fprintf(buf, "%s%s\n", prefix, s)
+		if noNewlineMarker {
+			fmt.Fprintln(buf, noNewline)
+		}
+	}
+	for _, h := range d.Hunks(opts.Context) {
+		if buf.Len() == 0 {
+			fmt.Fprintf(buf, "--- %s\n+++ %s\n", opts.FromFile, opts.ToFile)
+		}
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(h.AStart, h.ALen), hunkRange(h.BStart, h.BLen))
+
+		// within a changed region, all deletions are listed before the additions
+		dels, adds := []delta.Line{}, []delta.Line{}
+		flush := func() {
+			for _, l := range dels {
+				writeLine("-", original(opts.FromText, l.A, l.ALine), aNoNewline && l.ALine == aCount)
+			}
+			for _, l := range adds {
+				writeLine("+", original(opts.ToText, l.B, l.BLine), bNoNewline && l.BLine == bCount)
+			}
+			dels, adds = dels[:0], adds[:0]
+		}
+		for _, l := range h.Lines {
+			if !l.Changed() && l.InA() && l.InB() {
+				flush()
+				writeLine(" ", original(opts.FromText, l.A, l.ALine), aNoNewline && l.ALine == aCount)
+				continue
+			}
+			if l.ALine != 0 {
+				dels = append(dels, l)
+			}
+			if l.BLine != 0 {
+				adds = append(adds, l)
+			}
+		}
+		flush()
+	}
+	return buf.String()
+}
+
+// original returns the given line n of the decoded file t in its original
+// encoding. Lines are returned as is if t is nil or UTF-16.
+func original(t *delta.Text, line string, n int) string {
+	if t == nil || isUTF16(t) {
+		return line
+	}
+	if n == 1 && t.BOM {
+		line = "\ufeff" + line
+	}
+	return string(t.Encoding.Encode(line))
+}
+
+func isUTF16(t *delta.Text) bool {
+	return t.Encoding == delta.EncodingUTF16LE || t.Encoding == delta.EncodingUTF16BE
+}
+
+// hasBOM returns true if the lines of t are written with a byte order mark
+// by original.
+func hasBOM(t *delta.Text) bool {
+	return t != nil && t.BOM && !isUTF16(t)
+}
+
+// splitFirstLine returns a copy of the solution in which the first line is
+// deleted and added if it is matched, so that the patch adds or removes a
+// byte order mark even if the decoded files are equal.
+func splitFirstLine(d *delta.DiffSolution) *delta.DiffSolution {
+	lines := d.TypedLines()
+	for i, l := range lines {
+		if l.ALine == 1 && l.BLine == 1 {
+			split := []delta.Line{{A: l.A, Source: delta.LineFromA}, {B: l.B, Source: delta.LineFromB}}
+			lines = append(lines[:i], append(split, lines[i+1:]...)...)
+			break
+		}
+	}
+	return delta.NewDiffSolution(lines)
+}
+
+// hunkRange formats the line range of a hunk. As in diff -u, the length is
+// omitted if it is 1.
+func hunkRange(start, length int) string {
+	if length == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
+
+// trimNewlines returns a copy of the solution without the empty last line
+// which is present if a file ends with a newline, and reports whether A and
+// B are missing a trailing newline. Matched last lines are split into a
+// deletion and an addition if only one of them is missing a newline.
+func trimNewlines(d *delta.DiffSolution) (t *delta.DiffSolution, aNoNewline, bNoNewline bool) {
+	lines := d.TypedLines()
+	aLast, bLast := -1, -1
+	for i, l := range lines {
+		if l.InA() {
+			aLast = i
+		}
+		if l.InB() {
+			bLast = i
+		}
+	}
+	aNoNewline = aLast != -1 && lines[aLast].A != ""
+	bNoNewline = bLast != -1 && lines[bLast].B != ""
+
+	trimmed := []delta.Line{}
+	for i, l := range lines {
+		inA := l.InA() && !(i == aLast && !aNoNewline)
+		inB := l.InB() && !(i == bLast && !bNoNewline)
+		switch {
+		case inA && inB && (i == aLast && aNoNewline) != (i == bLast && bNoNewline):
+			trimmed = append(trimmed,
+				delta.Line{A: l.A, Source: delta.LineFromA},
+				delta.Line{B: l.B, Source: delta.LineFromB},
+			)
+		case inA && inB, inA && !l.InB(), inB && !l.InA():
+			trimmed = append(trimmed, l)
+		case inA:
+			trimmed = append(trimmed, delta.Line{A: l.A, Source: delta.LineFromA})
+		case inB:
+			trimmed = append(trimmed, delta.Line{B: l.B, Source: delta.LineFromB})
+		}
+	}
+	return delta.NewDiffSolution(trimmed), aNoNewline, bNoNewline
+}
@@ -0,0 +1,95 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/octavore/delta/lib"
+)
+
+func TestUnified(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk"
+	e := `--- a.txt
++++ b.txt
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+\ No newline at end of file
+`
+	u := Unified(delta.HistogramDiff(a, b), UnifiedOptions{
+		FromFile: "a.txt",
+		ToFile:   "b.txt",
+		Context:  DefaultContext,
+	})
+	if u != e {
+		t.Errorf("expected:\n%s\nbut got:\n%s", e, u)
+	}
+}
+
+func TestUnifiedNoChanges(t *testing.T) {
+	u := Unified(delta.HistogramDiff("a\nb\n", "a\nb\n"), UnifiedOptions{Context: DefaultContext})
+	if u != "" {
+		t.Errorf("expected no output but got:\n%s", u)
+	}
+}
+
+func TestUnifiedEncoding(t *testing.T) {
+	a := delta.DecodeText([]byte("\xef\xbb\xbfa\nb\n"))
+	b := delta.DecodeText([]byte("caf\xe9\nb\n"))
+	e := "--- a.txt\n+++ b.txt\n@@ -1,2 +1,2 @@\n-\xef\xbb\xbfa\n+caf\xe9\n b\n"
+	u := Unified(delta.HistogramDiff(a.Content, b.Content), UnifiedOptions{
+		FromFile: "a.txt",
+		ToFile:   "b.txt",
+		Context:  DefaultContext,
+		FromText: &a,
+		ToText:   &b,
+	})
+	if u != e {
+		t.Errorf("expected:\n%q\nbut got:\n%q", e, u)
+	}
+}
+
+func TestUnifiedBOM(t *testing.T) {
+	// only the byte order mark is changed, so the first line is replaced
+	tests := []struct {
+		a, b, e string
+	}{
+		{"\xef\xbb\xbfa\nb\n", "a\nb\n", "@@ -1 +1 @@\n-\xef\xbb\xbfa\n+a\n"},
+		{"a\nb\n", "\xef\xbb\xbfa\nb\n", "@@ -1 +1 @@\n-a\n+\xef\xbb\xbfa\n"},
+		{"\xef\xbb\xbfa", "a", "@@ -1 +1 @@\n-\xef\xbb\xbfa\n" + noNewline + "\n+a\n" + noNewline + "\n"},
+	}
+	for _, tt := range tests {
+		a, b := delta.DecodeText([]byte(tt.a)), delta.DecodeText([]byte(tt.b))
+		u := Unified(delta.HistogramDiff(a.Content, b.Content), UnifiedOptions{
+			FromFile: "a.txt",
+			ToFile:   "b.txt",
+			FromText: &a,
+			ToText:   &b,
+		})
+		if e := "--- a.txt\n+++ b.txt\n" + tt.e; u != e {
+			t.Errorf("expected:\n%q\nbut got:\n%q", e, u)
+		}
+	}
+}
+
+func TestBinaryUnified(t *testing.T) {
+	b := delta.NewBinaryDiff([]byte("a\x00"), []byte("b\x00"))
+	opts := UnifiedOptions{FromFile: "a/x.png", ToFile: "b/x.png"}
+	e := "Binary files a/x.png and b/x.png differ " +
+		"(2 bytes, sha256 ffe9aaeaa2a2 -> 2 bytes, sha256 1e57b933b0a7)\n"
+	if u := BinaryUnified(b, opts); u != e {
+		t.Errorf("expected %q but got %q", e, u)
+	}
+	if u := BinaryUnified(delta.NewBinaryDiff([]byte("a"), []byte("a")), opts); u != "" {
+		t.Errorf("expected no output but got %q", u)
+	}
+}
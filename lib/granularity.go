@@ -0,0 +1,212 @@
+package delta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Granularity controls how DiffLineGranularity splits lines into tokens.
+type Granularity string
+
+// These are valid values for Granularity.
+const (
+	// GranularityWord splits lines into words, see SplitWords.
+	GranularityWord Granularity = "word"
+	// GranularityChar splits lines into characters (runes).
+	GranularityChar Granularity = "char"
+	// GranularityGrapheme splits lines into user-perceived characters, so
+	// that e.g. a letter and its accents are compared as one token.
+	GranularityGrapheme Granularity = "grapheme"
+)
+
+var granularities = []Granularity{
+	GranularityWord,
+	GranularityChar,
+	GranularityGrapheme,
+}
+
+// ParseGranularity returns the Granularity with the given name.
+func ParseGranularity(name string) (Granularity, error) {
+	names := []string{}
+	for _, g := range granularities {
+		if string(g) == name {
+			return g, nil
+		}
+		names = append(names, string(g))
+	}
+	return "", fmt.Errorf("unknown granularity %q, valid granularities are: %s",
+		name, strings.Join(names, ", "))
+}
+
+// Split splits s into tokens of the given granularity. Unknown
+// granularities split s into words.
+func (g Granularity) Split(s string) []string {
+	switch g {
+	case GranularityChar:
+		return SplitChars(s)
+	case GranularityGrapheme:
+		return SplitGraphemes(s)
+	}
+	return SplitWords(s)
+}
+
+// MaxLineCells is the largest number of cells (tokens of A times tokens of
+// B) for which DiffLineGranularity diffs the tokens of two lines. The time
+// taken is proportional to the number of cells, so longer lines, e.g.
+// minified code, are not split into tokens.
+const MaxLineCells = 1 << 22
+
+// DiffLineGranularity diffs two lines, comparing tokens of the given
+// granularity. If there are more than MaxLineCells cells, the lines are
+// diffed as a single token.
+func DiffLineGranularity(a, b string, g Granularity) *DiffSolution {
+	d, _ := DiffLineContext(context.Background(), a, b, g)
+	return d
+}
+
+// DiffLineContext is like DiffLineGranularity, but stops early if ctx is
+// done, in which case the lines are diffed as a single token and ctx.Err()
+// is returned.
+func DiffLineContext(ctx context.Context, a, b string, g Granularity) (*DiffSolution, error) {
+	if err := ctx.Err(); err != nil {
+		return wholeLine(a, b), err
+	}
+	at, bt := g.Split(a), g.Split(b)
+	if len(at)*len(bt) > MaxLineCells {
+		return wholeLine(a, b), nil
+	}
+	d, err := NewSequenceDiffer(at, bt).SolveContext(ctx)
+	if err != nil {
+		return wholeLine(a, b), err
+	}
+	return d, nil
+}
+
+// wholeLine returns the diff of the lines a and b as a single token.
+func wholeLine(a, b string) *DiffSolution {
+	source := LineFromBothEdit
+	if a == b {
+		source = LineFromBoth
+	}
+	return NewDiffSolution([]Line{{A: a, B: b, Source: source, ALine: 1, BLine: 1}})
+}
+
+// SplitChars splits s into characters (runes). Each byte of invalid UTF-8
+// is a separate character.
+func SplitChars(s string) []string {
+	cs := make([]string, 0, len(s))
+	for i := 0; i < len(s); {
+		_, n := utf8.DecodeRuneInString(s[i:])
+		cs = append(cs, s[i:i+n])
+		i += n
+	}
+	return cs
+}
+
+// SplitGraphemes splits s into grapheme clusters, using a simplified version
+// of the rules of Unicode Standard Annex #29: combining marks, emoji
+// modifiers and zero width joiner sequences, regional indicator pairs
+// (flags), Hangul syllables and CRLF are kept together.
+func SplitGraphemes(s string) []string {
+	gs := []string{}
+	start := 0
+	prev, regional := rune(-1), 0
+	for i, r := range s {
+		if prev >= 0 && graphemeBreak(prev, r, regional) {
+			gs = append(gs, s[start:i])
+			start = i
+			regional = 0
+		}
+		if isRegionalIndicator(r) {
+			regional++
+		}
+		prev = r
+	}
+	if start < len(s) {
+		gs = append(gs, s[start:])
+	}
+	return gs
+}
+
+const zeroWidthJoiner = '‍'
+
+// graphemeBreak returns true if there is a grapheme cluster boundary
+// between the runes prev and r, where regional is the number of regional
+// indicators in the current cluster.
+func graphemeBreak(prev, r rune, regional int) bool {
+	switch {
+	case prev == '\r' && r == '\n':
+		return false
+	case isControl(prev) || isControl(r):
+		return true
+	case hangulJoins(prev, r):
+		return false
+	case isExtend(r) || unicode.Is(unicode.Mc, r):
+		return false
+	case prev == zeroWidthJoiner && isPictographic(r):
+		return false
+	case isRegionalIndicator(prev) && isRegionalIndicator(r):
+		return regional%2 == 0
+	}
+	return true
+}
+
+func isControl(r rune) bool {
+	return r == '\r' || r == '\n' || unicode.Is(unicode.Cc, r) ||
+		unicode.Is(unicode.Zl, r) || unicode.Is(unicode.Zp, r)
+}
+
+func isExtend(r rune) bool {
+	return unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) ||
+		r == zeroWidthJoiner || (r >= 0x1f3fb && r <= 0x1f3ff) // emoji modifiers
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// isPictographic approximates the Extended_Pictographic property, which is
+// not available in the unicode package.
+func isPictographic(r rune) bool {
+	return (r >= 0x2190 && r <= 0x2bff) || r == 0x00a9 || r == 0x00ae ||
+		r == 0x203c || r == 0x2049 || r == 0x2122 || r == 0x2139 ||
+		(r >= 0x1f000 && r <= 0x1faff)
+}
+
+// hangulJoins returns true if the Hangul jamo or syllables prev and r are
+// part of the same syllable.
+func hangulJoins(prev, r rune) bool {
+	p, n := hangulType(prev), hangulType(r)
+	switch p {
+	case 'L':
+		return n == 'L' || n == 'V' || n == 'S' || n == 's'
+	case 'V', 's':
+		return n == 'V' || n == 'T'
+	case 'T', 'S':
+		return n == 'T'
+	}
+	return false
+}
+
+// hangulType returns the Hangul syllable type of r: L, V or T for leading,
+// vowel and trailing jamo, s for syllables without a trailing consonant
+// (LV), S for syllables with one (LVT), or 0 for other runes.
+func hangulType(r rune) byte {
+	switch {
+	case r >= 0x1100 && r <= 0x115f, r >= 0xa960 && r <= 0xa97c:
+		return 'L'
+	case r >= 0x1160 && r <= 0x11a7, r >= 0xd7b0 && r <= 0xd7c6:
+		return 'V'
+	case r >= 0x11a8 && r <= 0x11ff, r >= 0xd7cb && r <= 0xd7fb:
+		return 'T'
+	case r >= 0xac00 && r <= 0xd7a3:
+		if (r-0xac00)%28 == 0 {
+			return 's'
+		}
+		return 'S'
+	}
+	return 0
+}
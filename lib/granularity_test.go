@@ -0,0 +1,111 @@
+package delta
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitGraphemes(t *testing.T) {
+	tests := []struct {
+		s string
+		e []string
+	}{
+		{"abc", []string{"a", "b", "c"}},
+		{"éa", []string{"é", "a"}},
+		{"a\r\nb", []string{"a", "\r\n", "b"}},
+		{"🇯🇵🇫🇷", []string{"🇯🇵", "🇫🇷"}},
+		{"👍🏽!", []string{"👍🏽", "!"}},
+		{"👩\u200d💻x", []string{"👩\u200d💻", "x"}},
+		{"각가", []string{"각", "가"}},
+		{"caf\xe9!", []string{"c", "a", "f", "\xe9", "!"}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if g := SplitGraphemes(tt.s); !reflect.DeepEqual(g, tt.e) {
+			t.Errorf("%q: expected %q but got %q", tt.s, tt.e, g)
+		}
+	}
+}
+
+func TestSplitChars(t *testing.T) {
+	tests := []struct {
+		s string
+		e []string
+	}{
+		{"aé", []string{"a", "é"}},
+		{"caf\xe9", []string{"c", "a", "f", "\xe9"}},
+		{"\xe2\x82x", []string{"\xe2", "\x82", "x"}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if c := SplitChars(tt.s); !reflect.DeepEqual(c, tt.e) {
+			t.Errorf("%q: expected %q but got %q", tt.s, tt.e, c)
+		}
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		s string
+		e []string
+	}{
+		{"the cat, sat", []string{"the ", "cat,", " ", "sat"}},
+		{"caf\xe9 au", []string{"caf\xe9", " ", "au"}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if w := SplitWords(tt.s); !reflect.DeepEqual(w, tt.e) {
+			t.Errorf("%q: expected %q but got %q", tt.s, tt.e, w)
+		}
+	}
+}
+
+func TestDiffLineGranularity(t *testing.T) {
+	tests := []struct {
+		g Granularity
+		e [][3]string
+	}{
+		{GranularityWord, [][3]string{{"getValue", "getValues", "~"}}},
+		{GranularityChar, [][3]string{
+			{"g", "g", "="}, {"e", "e", "="}, {"t", "t", "="}, {"V", "V", "="},
+			{"a", "a", "="}, {"l", "l", "="}, {"u", "u", "="}, {"e", "e", "="},
+			{"", "s", ">"},
+		}},
+	}
+	for _, tt := range tests {
+		if d := DiffLineGranularity("getValue", "getValues", tt.g); !reflect.DeepEqual(d.Lines, tt.e) {
+			t.Errorf("%s: expected %q but got %q", tt.g, tt.e, d.Lines)
+		}
+	}
+
+	// graphemes are not split into combining marks
+	d := DiffLineGranularity("café", "cafe", GranularityGrapheme)
+	if n := len(d.Lines); n != 4 || d.Lines[3] != [3]string{"é", "e", "~"} {
+		t.Errorf("unexpected grapheme diff: %q", d.Lines)
+	}
+
+	// long lines are diffed as a whole
+	a, b := strings.Repeat("a", 1<<11), strings.Repeat("a", 1<<11-1)+"b"
+	if d := DiffLineGranularity(a, b, GranularityChar); len(d.Lines) == 1 {
+		t.Errorf("expected lines within MaxLineCells to be split")
+	}
+	b += "b"
+	e := [][3]string{{a, b, "~"}}
+	if d := DiffLineGranularity(a, b, GranularityChar); !reflect.DeepEqual(d.Lines, e) {
+		t.Errorf("expected a single edit for lines over MaxLineCells but got %d tokens", len(d.Lines))
+	}
+
+	// lines are diffed as a whole once the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d, err := DiffLineContext(ctx, "getValue", "getValues", GranularityChar)
+	if e := [][3]string{{"getValue", "getValues", "~"}}; err != context.Canceled || !reflect.DeepEqual(d.Lines, e) {
+		t.Errorf("expected a single edit but got %q: %v", d.Lines, err)
+	}
+
+	if _, err := ParseGranularity("line"); err == nil {
+		t.Errorf("expected error for unknown granularity")
+	}
+}
@@ -1,9 +1,18 @@
 package delta
 
 import (
+	"context"
 	"strings"
 )
 
+func init() {
+	RegisterAlgorithm("histogram", func(a, b []string, w WhitespaceOptions) Solver {
+		h := NewHistogramDiffer(a, b)
+		h.SetWhitespace(w)
+		return h
+	})
+}
+
 // HistogramDiff uses the histogram diff algorithm to generate
 // a line-based diff between two strings
 func HistogramDiff(a, b string) *DiffSolution {
@@ -39,37 +48,112 @@ func (m *matchRegion) length() int {
 type HistogramDiffer struct {
 	a []string
 	b []string
+
+	whitespace  WhitespaceOptions
+	weights     Weights
+	postProcess bool
+	cancel      *cancellation
 }
 
-// NewHistogramDiffer returns a HistogramDiffer which diffs the given sequence of words.
+// NewHistogramDiffer returns a HistogramDiffer which diffs the given sequence
+// of words. Leading and trailing whitespace is ignored by default.
 func NewHistogramDiffer(a, b []string) *HistogramDiffer {
-	return &HistogramDiffer{a: a, b: b}
+	return &HistogramDiffer{
+		a:           a,
+		b:           b,
+		whitespace:  DefaultWhitespace,
+		weights:     DefaultWeights,
+		postProcess: true,
+	}
+}
+
+// SetWhitespace sets how whitespace is compared.
+func (h *HistogramDiffer) SetWhitespace(w WhitespaceOptions) {
+	h.whitespace = w
+}
+
+// SetWeights sets the weights used to diff the regions between matches.
+func (h *HistogramDiffer) SetWeights(w Weights) {
+	h.weights = w
+}
+
+// SetPostProcess sets whether PostProcess is called on the solution, which
+// is the default.
+func (h *HistogramDiffer) SetPostProcess(enabled bool) {
+	h.postProcess = enabled
+}
+
+// SolveContext is like Solve, but stops early if ctx is done, returning a
+// coarse solution and ctx.Err().
+func (h *HistogramDiffer) SolveContext(ctx context.Context) (*DiffSolution, error) {
+	h.cancel = &cancellation{ctx: ctx}
+	defer func() { h.cancel = nil }()
+	s := h.Solve()
+	if h.cancel.stopped() {
+		return coarseSolution(h.a, h.b, h.whitespace), h.cancel.err
+	}
+	return s, nil
+}
+
+// Solve returns a DiffSolution. Internally it uses solveRange to find
+// all matching regions, then it uses the standard differ to create diffs
+// on the intra-region area, falling back to the Myers differ for large areas.
+func (h *HistogramDiffer) Solve() *DiffSolution {
+	if h.whitespace.IgnoreBlankLines {
+		return solveWithoutBlankLines(h.a, h.b, h.whitespace, func(a, b []string) *DiffSolution {
+			d := NewHistogramDiffer(a, b)
+			d.SetWhitespace(h.whitespace.subregion())
+			d.SetWeights(h.weights)
+			d.SetPostProcess(h.postProcess)
+			d.cancel = h.cancel
+			return d.Solve()
+		})
+	}
+
+	a, b := h.whitespace.ids(h.a, h.b)
+	s := &script{}
+	solveHistogram(s, a, b, lineOptions(h.a, h.b, h.weights, h.cancel))
+	d := s.solution(h.a, h.b)
+	if h.postProcess {
+		d.PostProcess()
+	}
+	return d
+}
+
+// histogram implements the histogram diff algorithm on token ids.
+type histogram struct {
+	a []int
+	b []int
+	*solveOptions
 }
 
 // createAHistogram creates a map of lines to an array of line numbers
 // corresponding to occurrences of that particular line in the A input.
-func (h *HistogramDiffer) createAHistogram(aStart, aEnd int) map[string][]int {
-	histogram := map[string][]int{}
+func (h *histogram) createAHistogram(aStart, aEnd int) map[int][]int {
+	histogram := map[int][]int{}
 	for i := aStart; i < aEnd; i++ {
-		line := strings.TrimSpace(h.a[i])
+		line := h.a[i]
 		histogram[line] = append(histogram[line], i)
 	}
 	return histogram
 }
 
-func (h *HistogramDiffer) eq(aIdx, bIdx int) bool {
-	return strings.TrimSpace(h.a[aIdx]) == strings.TrimSpace(h.b[bIdx])
+func (h *histogram) eq(aIdx, bIdx int) bool {
+	return h.a[aIdx] == h.b[bIdx]
 }
 
 // longestSubstring finds the longest matching region in the given area of the
 // inputs A and B.
-func (h *HistogramDiffer) longestSubstring(aStart, aEnd, bStart, bEnd int) *matchRegion {
+func (h *histogram) longestSubstring(aStart, aEnd, bStart, bEnd int) *matchRegion {
 	var bestMatch *matchRegion
 	bestMatchScore := aEnd - aStart
 	histogram := h.createAHistogram(aStart, aEnd)
 	for bIdx := bStart; bIdx < bEnd; {
 		nextB := bIdx + 1
-		lineB := strings.TrimSpace(h.b[bIdx])
+		lineB := h.b[bIdx]
+		if h.cancel.done(1 + len(histogram[lineB])) {
+			return nil
+		}
 
 		// only consider low-occurence elements
 		if len(histogram[lineB]) > bestMatchScore {
@@ -101,16 +185,14 @@ func (h *HistogramDiffer) longestSubstring(aStart, aEnd, bStart, bEnd int) *matc
 				r.aStart--
 				r.bStart--
 				if r.matchScore > 1 {
-					trimmedAStart := strings.TrimSpace(h.a[r.aStart])
-					r.matchScore = min(r.matchScore, len(histogram[trimmedAStart]))
+					r.matchScore = min(r.matchScore, len(histogram[h.a[r.aStart]]))
 				}
 			}
 
 			// expand end of match region
 			for r.validEnd(aEnd, bEnd) && h.eq(r.aEnd, r.bEnd) {
 				if r.matchScore > 1 {
-					trimmedAEnd := strings.TrimSpace(h.a[r.aEnd])
-					r.matchScore = min(r.matchScore, len(histogram[trimmedAEnd]))
+					r.matchScore = min(r.matchScore, len(histogram[h.a[r.aEnd]]))
 				}
 				r.aEnd++
 				r.bEnd++
@@ -136,7 +218,7 @@ func (h *HistogramDiffer) longestSubstring(aStart, aEnd, bStart, bEnd int) *matc
 // solveRange finds the set of matching regions for the given sections
 // of A and B. First the longest matching region is found, then we recurse
 // on the area before the match, and then on the area after the match.
-func (h *HistogramDiffer) solveRange(aStart, aEnd, bStart, bEnd int) []*matchRegion {
+func (h *histogram) solveRange(aStart, aEnd, bStart, bEnd int) []*matchRegion {
 	if bEnd-bStart <= 1 {
 		return nil
 	}
@@ -152,34 +234,42 @@ func (h *HistogramDiffer) solveRange(aStart, aEnd, bStart, bEnd int) []*matchReg
 	return regions
 }
 
-// Solve returns a DiffSolution. Internally it uses solveRange to find
-// all matching regions, then it uses the standard differ to create diffs
-// on the intra-region area.
-func (h *HistogramDiffer) Solve() *DiffSolution {
-	s := &DiffSolution{}
+// solveHistogram adds the histogram diff of the token ids a and b to s.
+func solveHistogram(s *script, a, b []int, o *solveOptions) {
+	h := &histogram{a: a, b: b, solveOptions: o}
 	prevRegion := &matchRegion{aStart: 0, aEnd: 0, bStart: 0, bEnd: 0}
 	regions := h.solveRange(0, len(h.a), 0, len(h.b))
 	for _, region := range regions {
+		if h.cancel.stopped() {
+			return
+		}
+
 		// compute intra-region differences
-		a := h.a[prevRegion.aEnd:region.aStart]
-		b := h.b[prevRegion.bEnd:region.bStart]
-		s.addSolution(NewSequenceDiffer(a, b).Solve())
+		solveRegion(s, a[prevRegion.aEnd:region.aStart], b[prevRegion.bEnd:region.bStart], o)
 
 		// copy match region
-		for _, l := range h.a[region.aStart:region.aEnd] {
-			s.addLine(l, l, LineFromBoth)
-		}
+		s.add(LineFromBoth, region.length())
 
 		// update for loop
 		prevRegion = region
 	}
 
 	// compute diff for final unmatched section
-	a := h.a[prevRegion.aEnd:len(h.a)]
-	b := h.b[prevRegion.bEnd:len(h.b)]
-	s.addSolution(NewSequenceDiffer(a, b).Solve())
-	s.PostProcess()
-	return s
+	solveRegion(s, a[prevRegion.aEnd:], b[prevRegion.bEnd:], o)
+}
+
+// maxSequenceCells is the largest region (in lines of A times lines of B)
+// for which SequenceDiffer is used to compute intra-region differences.
+// Larger regions fall back to the linear space MyersDiffer.
+const maxSequenceCells = 1000000
+
+// solveRegion diffs an unmatched region between two match regions.
+func solveRegion(s *script, a, b []int, o *solveOptions) {
+	if len(a)*len(b) > maxSequenceCells {
+		solveMyers(s, a, b, o)
+		return
+	}
+	solveSequence(s, a, b, o)
 }
 
 func min(a, b int) int {
@@ -0,0 +1,83 @@
+package delta
+
+// Hunk is a region of changed lines in a DiffSolution, along with the
+// surrounding unchanged lines.
+type Hunk struct {
+	// AStart and BStart are the 1-indexed line numbers in A and B at which
+	// the hunk starts. As in the unified diff format, if the hunk contains
+	// no lines from A (or B), the start is the line preceding the hunk.
+	AStart, ALen int
+	BStart, BLen int
+
+	Lines []Line
+}
+
+// Hunks groups the changed lines of the solution into hunks, each with up
+// to context unchanged lines before and after the changes. Changes which are
+// separated by at most 2*context unchanged lines are placed in the same hunk.
+func (d *DiffSolution) Hunks(context int) []Hunk {
+	if context < 0 {
+		context = 0
+	}
+	lines := d.TypedLines()
+
+	// find the [start, end) ranges of lines in each hunk
+	ranges := [][2]int{}
+	lastChange := -1
+	for i, l := range lines {
+		if !l.Changed() {
+			continue
+		}
+		if len(ranges) == 0 || i-lastChange > 2*context+1 {
+			ranges = append(ranges, [2]int{max(i-context, 0), 0})
+		}
+		ranges[len(ranges)-1][1] = min(i+context+1, len(lines))
+		lastChange = i
+	}
+
+	hunks := make([]Hunk, len(ranges))
+	for i, r := range ranges {
+		h := &hunks[i]
+		h.Lines = lines[r[0]:r[1]]
+
+		// lines preceding the hunk, for empty ranges
+		for j := r[0] - 1; j >= 0 && (h.AStart == 0 || h.BStart == 0); j-- {
+			if h.AStart == 0 && lines[j].ALine != 0 {
+				h.AStart = lines[j].ALine
+			}
+			if h.BStart == 0 && lines[j].BLine != 0 {
+				h.BStart = lines[j].BLine
+			}
+		}
+
+		aStart, bStart := 0, 0
+		for _, l := range h.Lines {
+			if l.ALine != 0 {
+				if aStart == 0 {
+					aStart = l.ALine
+				}
+				h.ALen++
+			}
+			if l.BLine != 0 {
+				if bStart == 0 {
+					bStart = l.BLine
+				}
+				h.BLen++
+			}
+		}
+		if aStart != 0 {
+			h.AStart = aStart
+		}
+		if bStart != 0 {
+			h.BStart = bStart
+		}
+	}
+	return hunks
+}
+
+func max(a, b int) int {
+	if b > a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,46 @@
+package delta
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHunks(t *testing.T) {
+	d := NewDiffSolution([]Line{
+		{A: "A", B: "A", Source: LineFromBoth},
+		{A: "B", B: "", Source: LineFromA},
+		{A: "C", B: "C", Source: LineFromBoth},
+		{A: "D", B: "D", Source: LineFromBoth},
+		{A: "E", B: "E", Source: LineFromBoth},
+		{A: "F", B: "F", Source: LineFromBoth},
+		{A: "", B: "G", Source: LineFromB},
+		{A: "H", B: "I", Source: LineFromBothEdit},
+	})
+
+	e := []Hunk{
+		{
+			AStart: 1, ALen: 3, BStart: 1, BLen: 2,
+			Lines: []Line{
+				{A: "A", B: "A", Source: LineFromBoth, ALine: 1, BLine: 1},
+				{A: "B", B: "", Source: LineFromA, ALine: 2},
+				{A: "C", B: "C", Source: LineFromBoth, ALine: 3, BLine: 2},
+			},
+		},
+		{
+			AStart: 6, ALen: 2, BStart: 5, BLen: 3,
+			Lines: []Line{
+				{A: "F", B: "F", Source: LineFromBoth, ALine: 6, BLine: 5},
+				{A: "", B: "G", Source: LineFromB, BLine: 6},
+				{A: "H", B: "I", Source: LineFromBothEdit, ALine: 7, BLine: 7},
+			},
+		},
+	}
+	if h := d.Hunks(1); !reflect.DeepEqual(h, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, h)
+	}
+
+	// with more context, the changes are merged into a single hunk
+	if h := d.Hunks(2); len(h) != 1 || h[0].ALen != 7 || h[0].BLen != 7 {
+		t.Errorf("expected a single hunk but got:\n%+v", h)
+	}
+}
@@ -0,0 +1,79 @@
+package delta
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+)
+
+// svgSniffLen is the number of bytes searched for an <svg> tag by
+// ImageType.
+const svgSniffLen = 1024
+
+// ImageType returns the MIME type of data if it is a PNG, JPEG or SVG
+// image, or "" otherwise. SVG images are detected by an <svg> tag near the
+// start, after any XML declaration or comments.
+func ImageType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return "image/jpeg"
+	}
+	sample := data
+	if len(sample) > svgSniffLen {
+		sample = sample[:svgSniffLen]
+	}
+	if bytes.Contains(sample, []byte("<svg")) && !IsBinary(data) {
+		return "image/svg+xml"
+	}
+	return ""
+}
+
+// ImageDiff is the pixel by pixel comparison of two images.
+type ImageDiff struct {
+	// Mask has the size of the larger of the images. Changed pixels are
+	// red, and the others are a faded grayscale copy of the new image.
+	Mask *image.NRGBA
+
+	// Changed is the number of changed pixels, including pixels which are
+	// only in one of the images.
+	Changed int
+}
+
+// maskColor is the color of changed pixels in ImageDiff.Mask.
+var maskColor = color.NRGBA{R: 0xff, A: 0xff}
+
+// DiffImages compares the pixels of a and b, which are aligned at their
+// top left corners.
+func DiffImages(a, b image.Image) *ImageDiff {
+	ab, bb := a.Bounds(), b.Bounds()
+	w := max(ab.Dx(), bb.Dx())
+	h := max(ab.Dy(), bb.Dy())
+	d := &ImageDiff{Mask: image.NewNRGBA(image.Rect(0, 0, w, h))}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			pa := image.Pt(ab.Min.X+x, ab.Min.Y+y)
+			pb := image.Pt(bb.Min.X+x, bb.Min.Y+y)
+			if !pa.In(ab) || !pb.In(bb) {
+				d.Changed++
+				d.Mask.SetNRGBA(x, y, maskColor)
+				continue
+			}
+			ca := color.NRGBAModel.Convert(a.At(pa.X, pa.Y))
+			cb := color.NRGBAModel.Convert(b.At(pb.X, pb.Y))
+			if ca != cb {
+				d.Changed++
+				d.Mask.SetNRGBA(x, y, maskColor)
+				continue
+			}
+			// the gray of the pixel over a white background, faded so that
+			// changes stand out
+			_, _, _, alpha := cb.RGBA()
+			g := color.GrayModel.Convert(cb).(color.Gray).Y + uint8(0xff-alpha>>8)
+			v := 0xff - (0xff-g)/4
+			d.Mask.SetNRGBA(x, y, color.NRGBA{R: v, G: v, B: v, A: 0xff})
+		}
+	}
+	return d
+}
@@ -0,0 +1,53 @@
+package delta
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestImageType(t *testing.T) {
+	tests := []struct {
+		data string
+		e    string
+	}{
+		{"\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "image/png"},
+		{"\xff\xd8\xff\xe0\x00\x10JFIF", "image/jpeg"},
+		{"<?xml version=\"1.0\"?>\n<svg xmlns=\"http://www.w3.org/2000/svg\"/>\n", "image/svg+xml"},
+		{"a\nb\n", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if typ := ImageType([]byte(tt.data)); typ != tt.e {
+			t.Errorf("%q: expected %q but got %q", tt.data, tt.e, typ)
+		}
+	}
+}
+
+func TestDiffImages(t *testing.T) {
+	a := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	b := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for _, img := range []*image.NRGBA{a, b} {
+		for i := range img.Pix {
+			img.Pix[i] = 0xff
+		}
+	}
+	b.SetNRGBA(1, 1, color.NRGBA{A: 0xff})
+
+	d := DiffImages(a, b)
+	if s := d.Mask.Bounds().Size(); s != image.Pt(3, 2) {
+		t.Errorf("expected a 3x2 mask but got %v", s)
+	}
+	// the pixel at (1, 1) and the last column are changed
+	if d.Changed != 3 {
+		t.Errorf("expected 3 changed pixels but got %d", d.Changed)
+	}
+	for _, p := range []image.Point{{1, 1}, {2, 0}, {2, 1}} {
+		if c := d.Mask.NRGBAAt(p.X, p.Y); c != maskColor {
+			t.Errorf("%v: expected a changed pixel but got %v", p, c)
+		}
+	}
+	if c := d.Mask.NRGBAAt(0, 0); c != (color.NRGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("expected an unchanged white pixel but got %v", c)
+	}
+}
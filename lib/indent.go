@@ -0,0 +1,265 @@
+package delta
+
+// The indent heuristic is ported from git's xdiff (see xdl_change_compact).
+// The constants were tuned by git against a corpus of real diffs.
+const (
+	startOfFilePenalty              = 1
+	endOfFilePenalty                = 21
+	totalBlankWeight                = -30
+	postBlankWeight                 = 6
+	relativeIndentPenalty           = -4
+	relativeIndentWithBlankPenalty  = 10
+	relativeOutdentPenalty          = 24
+	relativeOutdentWithBlankPenalty = 17
+	relativeDedentPenalty           = 23
+	relativeDedentWithBlankPenalty  = 17
+	indentWeight                    = 60
+
+	maxIndentSliding = 100
+	maxIndent        = 200
+	maxBlanks        = 20
+)
+
+// PostProcessIndent is an alternative to PostProcess which uses git's indent
+// heuristic. PostProcess moves each added or deleted region as far down as
+// possible, which can split a new function across the end of the previous
+// one and the start of the next:
+//
+//	  package p
+//	  func init() {
+//	+ 	register("a")
+//	+ }
+//	+
+//	+ func init() {
+//	  	register("b")
+//	  }
+//
+// Instead, the indent heuristic moves each region to the position which
+// best fits the blank lines and indentation around it:
+//
+//	  package p
+//	+ func init() {
+//	+ 	register("a")
+//	+ }
+//	+
+//	  func init() {
+//	  	register("b")
+//	  }
+func (d *DiffSolution) PostProcessIndent() {
+	lines := d.TypedLines()
+	files := [2][]string{}
+	for _, l := range lines {
+		if l.InA() {
+			files[0] = append(files[0], l.A)
+		}
+		if l.InB() {
+			files[1] = append(files[1], l.B)
+		}
+	}
+
+	// counts are the number of lines of A and B before lines[i]
+	counts := [2]int{}
+	for i := 0; i < len(lines); {
+		source := lines[i].Source
+		side := 0
+		switch source {
+		case LineFromA:
+		case LineFromB:
+			side = 1
+		default:
+			if lines[i].InA() {
+				counts[0]++
+			}
+			if lines[i].InB() {
+				counts[1]++
+			}
+			i++
+			continue
+		}
+
+		start, end := i, i
+		for end < len(lines) && lines[end].Source == source {
+			end++
+		}
+		text := func(l Line) string {
+			if side == 0 {
+				return l.A
+			}
+			return l.B
+		}
+		up := 0
+		for start-up > 0 && lines[start-up-1].Source == LineFromBoth &&
+			text(lines[start-up-1]) == text(lines[end-up-1]) {
+			up++
+		}
+		down := 0
+		for end+down < len(lines) && lines[end+down].Source == LineFromBoth &&
+			text(lines[end+down]) == text(lines[start+down]) {
+			down++
+		}
+
+		shift := 0
+		if up > 0 || down > 0 {
+			shift = bestShift(files[side], counts[side]+end-start+down, end-start, up+down) - up
+		}
+		slide(lines, start, end, shift)
+
+		n := end - start
+		counts[0] += shift
+		counts[1] += shift
+		counts[side] += n
+		i = end + shift
+	}
+	d.SetLines(lines)
+}
+
+// bestShift returns the best position of a region of n lines of file which
+// ends at line end when slid down as far as possible, and which can be slid
+// up by at most slack lines. The result is the number of lines the region
+// is slid down from its highest position.
+func bestShift(file []string, end, n, slack int) int {
+	earliest := end - slack
+	shift := max(max(earliest, end-n-1), end-maxIndentSliding)
+	best, bestScore := -1, splitScore{}
+	for ; shift <= end; shift++ {
+		s := splitScore{}
+		s.add(measureSplit(file, shift))
+		s.add(measureSplit(file, shift-n))
+		if best == -1 || s.cmp(bestScore) <= 0 {
+			best, bestScore = shift, s
+		}
+	}
+	return best - earliest
+}
+
+// slide moves the region lines[start:end] down by shift lines, or up if
+// shift is negative. The lines it is moved over must be equal to the lines
+// of the region.
+func slide(lines []Line, start, end, shift int) {
+	for ; shift > 0; shift-- {
+		lines[start], lines[end] = lines[end], lines[start]
+		start, end = start+1, end+1
+	}
+	for ; shift < 0; shift++ {
+		lines[start-1], lines[end-1] = lines[end-1], lines[start-1]
+		start, end = start-1, end-1
+	}
+}
+
+// indent returns the indentation width of line, counting tabs to the next
+// multiple of 8, or -1 if the line is blank.
+func indent(line string) int {
+	n := 0
+	for _, c := range []byte(line) {
+		switch c {
+		case ' ':
+			n++
+		case '\t':
+			n += 8 - n%8
+		case '\n', '\r', '\v', '\f':
+		default:
+			return n
+		}
+		if n >= maxIndent {
+			return maxIndent
+		}
+	}
+	return -1
+}
+
+// split describes the lines around a split between two lines of a file.
+type split struct {
+	endOfFile  bool
+	indent     int // indent of the line after the split
+	preBlank   int // number of blank lines before the split
+	preIndent  int // indent of the first non-blank line before the split
+	postBlank  int // number of blank lines after the line after the split
+	postIndent int
+}
+
+// measureSplit describes the split before file[i].
+func measureSplit(file []string, i int) split {
+	m := split{indent: -1, preIndent: -1, postIndent: -1}
+	if i >= len(file) {
+		m.endOfFile = true
+	} else {
+		m.indent = indent(file[i])
+	}
+	for j := i - 1; j >= 0; j-- {
+		if m.preIndent = indent(file[j]); m.preIndent != -1 {
+			break
+		}
+		if m.preBlank++; m.preBlank == maxBlanks {
+			m.preIndent = 0
+			break
+		}
+	}
+	for j := i + 1; j < len(file); j++ {
+		if m.postIndent = indent(file[j]); m.postIndent != -1 {
+			break
+		}
+		if m.postBlank++; m.postBlank == maxBlanks {
+			m.postIndent = 0
+			break
+		}
+	}
+	return m
+}
+
+// splitScore is the score of the splits at the start and end of a region.
+// Lower scores are better.
+type splitScore struct {
+	effectiveIndent int
+	penalty         int
+}
+
+func (s *splitScore) add(m split) {
+	if m.preIndent == -1 && m.preBlank == 0 {
+		s.penalty += startOfFilePenalty
+	}
+	if m.endOfFile {
+		s.penalty += endOfFilePenalty
+	}
+	postBlank := 0
+	if m.indent == -1 {
+		postBlank = 1 + m.postBlank
+	}
+	totalBlank := m.preBlank + postBlank
+	s.penalty += totalBlankWeight * totalBlank
+	s.penalty += postBlankWeight * postBlank
+
+	indent := m.indent
+	if indent == -1 {
+		indent = m.postIndent
+	}
+	anyBlanks := totalBlank != 0
+	s.effectiveIndent += indent
+
+	penalty := func(withBlank, without int) int {
+		if anyBlanks {
+			return withBlank
+		}
+		return without
+	}
+	switch {
+	case indent == -1 || m.preIndent == -1 || indent == m.preIndent:
+	case indent > m.preIndent:
+		s.penalty += penalty(relativeIndentWithBlankPenalty, relativeIndentPenalty)
+	case m.postIndent != -1 && m.postIndent > indent:
+		s.penalty += penalty(relativeOutdentWithBlankPenalty, relativeOutdentPenalty)
+	default:
+		s.penalty += penalty(relativeDedentWithBlankPenalty, relativeDedentPenalty)
+	}
+}
+
+// cmp returns a negative number if s is better than t, and a positive number
+// if it is worse.
+func (s splitScore) cmp(t splitScore) int {
+	c := 0
+	if s.effectiveIndent > t.effectiveIndent {
+		c = 1
+	} else if s.effectiveIndent < t.effectiveIndent {
+		c = -1
+	}
+	return indentWeight*c + s.penalty - t.penalty
+}
@@ -0,0 +1,65 @@
+package delta
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// renderLines renders lines like a unified diff without headers.
+func renderLines(lines []Line) string {
+	buf := &strings.Builder{}
+	for _, l := range lines {
+		switch l.Source {
+		case LineFromBoth:
+			buf.WriteString(" " + l.A + "\n")
+		case LineFromA:
+			buf.WriteString("-" + l.A + "\n")
+		case LineFromB:
+			buf.WriteString("+" + l.B + "\n")
+		default:
+			buf.WriteString("-" + l.A + "\n+" + l.B + "\n")
+		}
+	}
+	return buf.String()
+}
+
+// TestPostProcessIndent diffs each pair of files NAME.a and NAME.b in
+// testdata/indent, and compares the result with NAME.down, which is post
+// processed using PostProcess, and NAME.indent, which uses the indent
+// heuristic. Each case must be placed differently by the indent heuristic.
+func TestPostProcessIndent(t *testing.T) {
+	cases, err := filepath.Glob("testdata/indent/*.a")
+	if err != nil || len(cases) == 0 {
+		t.Fatalf("no test cases found: %v", err)
+	}
+	read := func(name string) string {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	for _, c := range cases {
+		name := strings.TrimSuffix(c, ".a")
+		a, b := read(name+".a"), read(name+".b")
+		if read(name+".down") == read(name+".indent") {
+			t.Errorf("%s: the indent heuristic does not change the diff", name)
+		}
+
+		d, _ := Diff(a, b)
+		if r, e := renderLines(d.TypedLines()), read(name+".down"); r != e {
+			t.Errorf("%s: expected:\n%s\nbut got:\n%s", name+".down", e, r)
+		}
+		for _, alg := range Algorithms() {
+			d, err := Diff(a, b, WithAlgorithm(alg), WithIndentHeuristic(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r, e := renderLines(d.TypedLines()), read(name+".indent"); r != e {
+				t.Errorf("%s (%s): expected:\n%s\nbut got:\n%s", name+".indent", alg, e, r)
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package delta
+
+import (
+	"strings"
+)
+
+// ConflictStyle controls how conflicts are written by Merge.
+type ConflictStyle string
+
+// These are valid values for ConflictStyle, matching git's merge.conflictStyle.
+const (
+	// ConflictStyleMerge shows the local and remote lines of a conflict.
+	ConflictStyleMerge ConflictStyle = "merge"
+	// ConflictStyleDiff3 additionally shows the base lines of a conflict.
+	ConflictStyleDiff3 ConflictStyle = "diff3"
+)
+
+// MergeOptions configures Merge.
+type MergeOptions struct {
+	Style ConflictStyle
+
+	// LocalName, BaseName and RemoteName are shown after the conflict markers.
+	LocalName, BaseName, RemoteName string
+}
+
+// change is a region of base which was replaced by lines in one of the
+// merged files. start is inclusive but end is exclusive.
+type change struct {
+	start, end int
+	lines      []string
+	local      bool // whether the change is from local or remote
+}
+
+// changes returns the regions of A which are changed in the solution.
+// Lines which are matched but not identical (e.g. differ in whitespace) are
+// treated as changes, so that no changes are lost when merging.
+func (d *DiffSolution) changes(local bool) []change {
+	changes := []change{}
+	var c *change
+	ai := 0
+	for _, l := range d.TypedLines() {
+		if !l.Changed() {
+			c = nil
+			ai++
+			continue
+		}
+		if c == nil {
+			changes = append(changes, change{start: ai, end: ai, local: local})
+			c = &changes[len(changes)-1]
+		}
+		if l.InA() {
+			ai++
+			c.end = ai
+		}
+		if l.InB() {
+			c.lines = append(c.lines, l.B)
+		}
+	}
+	return changes
+}
+
+// Merge performs a three-way merge of local and remote, which were both
+// derived from base. Changes which do not overlap are merged automatically.
+// Overlapping changes are written with conflict markers as in git, and the
+// number of conflicts is returned.
+func Merge(base, local, remote string, opts MergeOptions) (string, int) {
+	baseLines := strings.Split(base, "\n")
+	localChanges := HistogramDiff(base, local).changes(true)
+	remoteChanges := HistogramDiff(base, remote).changes(false)
+
+	merged := []string{}
+	conflicts := 0
+	pos := 0
+	for len(localChanges) > 0 || len(remoteChanges) > 0 {
+		// find all changes which overlap with the first change
+		group := []change{}
+		var end int
+		next := func() *[]change {
+			if len(remoteChanges) == 0 ||
+				len(localChanges) > 0 && localChanges[0].start <= remoteChanges[0].start {
+				return &localChanges
+			}
+			return &remoteChanges
+		}
+		for len(localChanges) > 0 || len(remoteChanges) > 0 {
+			changes := next()
+			c := (*changes)[0]
+			if len(group) > 0 && !overlaps(group, c, end) {
+				break
+			}
+			group = append(group, c)
+			*changes = (*changes)[1:]
+			if len(group) == 1 || c.end > end {
+				end = c.end
+			}
+		}
+
+		start := group[0].start
+		merged = append(merged, baseLines[pos:start]...)
+		pos = end
+
+		localLines, localChanged := applyChanges(baseLines, start, end, group, true)
+		remoteLines, remoteChanged := applyChanges(baseLines, start, end, group, false)
+		switch {
+		case !remoteChanged:
+			merged = append(merged, localLines...)
+		case !localChanged:
+			merged = append(merged, remoteLines...)
+		case equalLines(localLines, remoteLines):
+			merged = append(merged, localLines...)
+		default:
+			conflicts++
+			merged = append(merged, conflictMarker("<<<<<<<", opts.LocalName))
+			merged = append(merged, localLines...)
+			if opts.Style == ConflictStyleDiff3 {
+				merged = append(merged, conflictMarker("|||||||", opts.BaseName))
+				merged = append(merged, baseLines[start:end]...)
+			}
+			merged = append(merged, "=======")
+			merged = append(merged, remoteLines...)
+			merged = append(merged, conflictMarker(">>>>>>>", opts.RemoteName))
+		}
+	}
+	merged = append(merged, baseLines[pos:]...)
+	return strings.Join(merged, "\n"), conflicts
+}
+
+// overlaps returns true if c overlaps with the group of changes ending at
+// end. Changes which are adjacent to a change from the other file also
+// overlap, since the order of the lines would be ambiguous.
+func overlaps(group []change, c change, end int) bool {
+	if c.start < end {
+		return true
+	}
+	if c.start > end {
+		return false
+	}
+	for _, g := range group {
+		if g.local != c.local && g.end == end {
+			return true
+		}
+	}
+	return false
+}
+
+// applyChanges applies the local or remote changes in the group to the
+// lines of base between start and end, and returns true if any changes
+// were applied.
+func applyChanges(base []string, start, end int, group []change, local bool) ([]string, bool) {
+	lines := []string{}
+	changed := false
+	pos := start
+	for _, c := range group {
+		if c.local != local {
+			continue
+		}
+		lines = append(lines, base[pos:c.start]...)
+		lines = append(lines, c.lines...)
+		pos = c.end
+		changed = true
+	}
+	lines = append(lines, base[pos:end]...)
+	return lines, changed
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func conflictMarker(marker, name string) string {
+	if name == "" {
+		return marker
+	}
+	return marker + " " + name
+}
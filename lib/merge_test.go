@@ -0,0 +1,41 @@
+package delta
+
+import (
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := "a\nb\nc\nd\ne\nf\n"
+	local := "a\nB\nc\nd\ne\nf\n"
+	remote := "a\nb\nc\nd\nE\nf\ng\n"
+	merged, conflicts := Merge(base, local, remote, MergeOptions{})
+	if e := "a\nB\nc\nd\nE\nf\ng\n"; merged != e || conflicts != 0 {
+		t.Errorf("expected:\n%s\nbut got %d conflicts:\n%s", e, conflicts, merged)
+	}
+
+	// the same change in both files is not a conflict
+	merged, conflicts = Merge(base, local, local, MergeOptions{})
+	if merged != local || conflicts != 0 {
+		t.Errorf("expected:\n%s\nbut got %d conflicts:\n%s", local, conflicts, merged)
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	base := "a\nb\nc\n"
+	local := "a\nx\nc\n"
+	remote := "a\ny\nc\n"
+	opts := MergeOptions{LocalName: "local", BaseName: "base", RemoteName: "remote"}
+
+	merged, conflicts := Merge(base, local, remote, opts)
+	e := "a\n<<<<<<< local\nx\n=======\ny\n>>>>>>> remote\nc\n"
+	if merged != e || conflicts != 1 {
+		t.Errorf("expected:\n%s\nbut got %d conflicts:\n%s", e, conflicts, merged)
+	}
+
+	opts.Style = ConflictStyleDiff3
+	merged, conflicts = Merge(base, local, remote, opts)
+	e = "a\n<<<<<<< local\nx\n||||||| base\nb\n=======\ny\n>>>>>>> remote\nc\n"
+	if merged != e || conflicts != 1 {
+		t.Errorf("expected:\n%s\nbut got %d conflicts:\n%s", e, conflicts, merged)
+	}
+}
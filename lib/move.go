@@ -0,0 +1,102 @@
+package delta
+
+import (
+	"strings"
+)
+
+// minMovedChars is the minimum number of non-whitespace characters in a
+// moved block, so that short lines such as closing braces are not detected
+// as moves. This matches git's --color-moved.
+const minMovedChars = 20
+
+// Move is a block of lines which was deleted from A and added at another
+// position in B.
+type Move struct {
+	AStart, BStart int // 1-indexed line numbers of the first line in A and B
+	Len            int
+}
+
+// moveKey returns the text of a line used to detect moves. Lines which
+// differ only in whitespace are considered to be moved.
+func moveKey(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// findMoves finds the longest blocks of consecutive lines with the from
+// source which match blocks of consecutive lines with the to source. It
+// returns the index of the first line of each block in lines.
+func findMoves(lines []Line, from, to LineSource) [][3]int {
+	deleted := map[string][]int{}
+	for i, l := range lines {
+		if l.Source == from {
+			key := moveKey(l.A)
+			deleted[key] = append(deleted[key], i)
+		}
+	}
+
+	moved := map[int]bool{}
+	moves := [][3]int{}
+	for j := 0; j < len(lines); j++ {
+		if lines[j].Source != to {
+			continue
+		}
+		best, bestLen := -1, 0
+		for _, i := range deleted[moveKey(lines[j].B)] {
+			n := 0
+			for i+n < len(lines) && j+n < len(lines) &&
+				lines[i+n].Source == from && !moved[i+n] &&
+				lines[j+n].Source == to &&
+				moveKey(lines[i+n].A) == moveKey(lines[j+n].B) {
+				n++
+			}
+			if n > bestLen {
+				best, bestLen = i, n
+			}
+		}
+		if best == -1 {
+			continue
+		}
+
+		chars := 0
+		for _, l := range lines[j : j+bestLen] {
+			chars += len(strings.Join(strings.Fields(l.B), ""))
+		}
+		if chars < minMovedChars {
+			continue
+		}
+		for k := 0; k < bestLen; k++ {
+			moved[best+k] = true
+		}
+		moves = append(moves, [3]int{best, j, bestLen})
+		j += bestLen - 1
+	}
+	return moves
+}
+
+// DetectMoves finds blocks of deleted lines which were added elsewhere
+// (ignoring whitespace), and marks them as LineMovedFrom and LineMovedTo
+// respectively. Blocks must contain at least 20 non-whitespace characters.
+func (d *DiffSolution) DetectMoves() {
+	lines := d.TypedLines()
+	for _, m := range findMoves(lines, LineFromA, LineFromB) {
+		for k := 0; k < m[2]; k++ {
+			lines[m[0]+k].Source = LineMovedFrom
+			lines[m[1]+k].Source = LineMovedTo
+		}
+	}
+	d.SetLines(lines)
+}
+
+// Moves returns the moved blocks in lines which were marked by DetectMoves.
+// The lines must be annotated with line numbers, as returned by TypedLines.
+func Moves(lines []Line) []Move {
+	moves := []Move{}
+	for _, m := range findMoves(lines, LineMovedFrom, LineMovedTo) {
+		moves = append(moves, Move{
+			AStart: lines[m[0]].ALine,
+			BStart: lines[m[1]].BLine,
+			Len:    m[2],
+		})
+	}
+	return moves
+}
@@ -0,0 +1,71 @@
+package delta
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectMoves(t *testing.T) {
+	a := strings.Join([]string{
+		"func a() {",
+		"  return someValue + 1",
+		"}",
+		"",
+		"func b() {",
+		"  return 2",
+		"}",
+		"",
+		"func c() {",
+		"  return 3",
+		"}",
+	}, "\n")
+	b := strings.Join([]string{
+		"func b() {",
+		"  return 2",
+		"}",
+		"",
+		"func c() {",
+		"  return 3",
+		"}",
+		"",
+		"func a() {",
+		"    return someValue + 1",
+		"}",
+	}, "\n")
+
+	d := HistogramDiff(a, b)
+	d.DetectMoves()
+	sources := []LineSource{}
+	for _, l := range d.TypedLines() {
+		if l.Changed() {
+			sources = append(sources, l.Source)
+		}
+	}
+	e := []LineSource{
+		LineMovedFrom, LineMovedFrom, LineMovedFrom, LineFromA,
+		LineFromB, LineMovedTo, LineMovedTo, LineMovedTo,
+	}
+	if !reflect.DeepEqual(sources, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, sources)
+	}
+
+	em := []Move{{AStart: 1, BStart: 9, Len: 3}}
+	if m := Moves(d.TypedLines()); !reflect.DeepEqual(m, em) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", em, m)
+	}
+
+	if r, err := Apply(a, d); err != nil || r != b {
+		t.Errorf("unexpected result %q: %v", r, err)
+	}
+}
+
+func TestDetectMovesShortBlocks(t *testing.T) {
+	d := HistogramDiff("}\na\nb\nc\nd", "a\nb\nc\nd\n}")
+	d.DetectMoves()
+	for _, l := range d.TypedLines() {
+		if l.Source == LineMovedFrom || l.Source == LineMovedTo {
+			t.Errorf("unexpected move: %+v", l)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package delta
+
+import (
+	"context"
+	"strings"
+)
+
+func init() {
+	RegisterAlgorithm("myers", func(a, b []string, w WhitespaceOptions) Solver {
+		d := NewMyersDiffer(a, b)
+		d.SetWhitespace(w)
+		return d
+	})
+}
+
+// MyersDiff uses the Myers O(ND) diff algorithm to generate a line-based
+// diff between two strings.
+func MyersDiff(a, b string) *DiffSolution {
+	aw := strings.Split(a, "\n")
+	bw := strings.Split(b, "\n")
+	d := NewMyersDiffer(aw, bw)
+	d.SetWhitespace(DefaultWhitespace)
+	return d.Solve()
+}
+
+// MyersDiffer implements the linear space variant of the Myers O(ND) diff
+// algorithm, which recursively divides the inputs around a "middle snake".
+// Unlike SequenceDiffer, memory use is proportional to len(a)+len(b), so it
+// is suitable for very large inputs.
+type MyersDiffer struct {
+	a []string
+	b []string
+
+	whitespace WhitespaceOptions
+	cancel     *cancellation
+}
+
+// NewMyersDiffer returns a MyersDiffer which diffs the given sequence of words.
+func NewMyersDiffer(a, b []string) *MyersDiffer {
+	return &MyersDiffer{a: a, b: b}
+}
+
+// SetWhitespace sets how whitespace is compared. By default lines are
+// compared exactly.
+func (d *MyersDiffer) SetWhitespace(w WhitespaceOptions) {
+	d.whitespace = w
+}
+
+// SolveContext is like Solve, but stops early if ctx is done, returning a
+// coarse solution and ctx.Err().
+func (d *MyersDiffer) SolveContext(ctx context.Context) (*DiffSolution, error) {
+	d.cancel = &cancellation{ctx: ctx}
+	defer func() { d.cancel = nil }()
+	s := d.Solve()
+	if d.cancel.stopped() {
+		return coarseSolution(d.a, d.b, d.whitespace), d.cancel.err
+	}
+	return s, nil
+}
+
+// Solve returns a DiffSolution containing a minimal set of additions
+// and deletions.
+func (d *MyersDiffer) Solve() *DiffSolution {
+	if d.whitespace.IgnoreBlankLines {
+		return solveWithoutBlankLines(d.a, d.b, d.whitespace, func(a, b []string) *DiffSolution {
+			e := NewMyersDiffer(a, b)
+			e.SetWhitespace(d.whitespace.subregion())
+			e.cancel = d.cancel
+			return e.Solve()
+		})
+	}
+
+	a, b := d.whitespace.ids(d.a, d.b)
+	s := &script{}
+	solveMyers(s, a, b, &solveOptions{cancel: d.cancel})
+	return s.solution(d.a, d.b)
+}
+
+// myers implements the Myers diff algorithm on token ids.
+type myers struct {
+	a []int
+	b []int
+	*solveOptions
+}
+
+// solveMyers adds the diff of the token ids a and b to s.
+func solveMyers(s *script, a, b []int, o *solveOptions) {
+	d := &myers{a: a, b: b, solveOptions: o}
+	d.solveRange(s, 0, len(a), 0, len(b))
+}
+
+func (d *myers) eq(aIdx, bIdx int) bool {
+	return d.a[aIdx] == d.b[bIdx]
+}
+
+// solveRange appends the diff of a[aStart:aEnd] and b[bStart:bEnd] to s.
+// Shared prefixes and suffixes are copied over directly, then the remaining
+// region is split on the middle snake and each half is solved recursively.
+func (d *myers) solveRange(s *script, aStart, aEnd, bStart, bEnd int) {
+	if d.cancel.stopped() {
+		return
+	}
+
+	// copy over shared prefix
+	prefix := 0
+	for aStart < aEnd && bStart < bEnd && d.eq(aStart, bStart) {
+		aStart++
+		bStart++
+		prefix++
+	}
+	s.add(LineFromBoth, prefix)
+
+	// find shared suffix, which is copied over at the end
+	suffix := 0
+	for aStart < aEnd-suffix && bStart < bEnd-suffix && d.eq(aEnd-suffix-1, bEnd-suffix-1) {
+		suffix++
+	}
+	aEnd -= suffix
+	bEnd -= suffix
+
+	switch {
+	case aStart == aEnd:
+		s.add(LineFromB, bEnd-bStart)
+	case bStart == bEnd:
+		s.add(LineFromA, aEnd-aStart)
+	default:
+		x, y, u, v := d.middleSnake(aStart, aEnd, bStart, bEnd)
+		d.solveRange(s, aStart, x, bStart, y)
+		s.add(LineFromBoth, u-x)
+		d.solveRange(s, u, aEnd, v, bEnd)
+	}
+
+	s.add(LineFromBoth, suffix)
+}
+
+// middleSnake finds the middle snake of an optimal edit path through the
+// given region, by searching forward from the start and backward from the
+// end until the two searches overlap. The snake starts at (x, y) and ends
+// at (u, v), in absolute indexes of A and B.
+func (d *myers) middleSnake(aStart, aEnd, bStart, bEnd int) (x, y, u, v int) {
+	n, m := aEnd-aStart, bEnd-bStart
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+
+	// vf[offset+k] is the furthest reaching x on diagonal k searching forward,
+	// vb[offset+k] is the furthest reaching x on diagonal k searching backward
+	// (measured from the end of the region).
+	offset := maxD + 1
+	vf := make([]int, 2*offset+1)
+	vb := make([]int, 2*offset+1)
+
+	for D := 0; D <= maxD; D++ {
+		if d.cancel.done(2*D + 1) {
+			// return an empty snake, the solution is discarded
+			return aStart, bStart, aStart, bStart
+		}
+
+		// forward search
+		for k := -D; k <= D; k += 2 {
+			var xs int
+			if k == -D || (k != D && vf[offset+k-1] < vf[offset+k+1]) {
+				xs = vf[offset+k+1]
+			} else {
+				xs = vf[offset+k-1] + 1
+			}
+			ys := xs - k
+			xe, ye := xs, ys
+			for xe < n && ye < m && d.eq(aStart+xe, bStart+ye) {
+				xe++
+				ye++
+			}
+			vf[offset+k] = xe
+
+			c := delta - k
+			if odd && c >= -(D-1) && c <= D-1 && xe+vb[offset+c] >= n {
+				return aStart + xs, bStart + ys, aStart + xe, bStart + ye
+			}
+		}
+
+		// backward search
+		for c := -D; c <= D; c += 2 {
+			var xs int
+			if c == -D || (c != D && vb[offset+c-1] < vb[offset+c+1]) {
+				xs = vb[offset+c+1]
+			} else {
+				xs = vb[offset+c-1] + 1
+			}
+			ys := xs - c
+			xe, ye := xs, ys
+			for xe < n && ye < m && d.eq(aEnd-xe-1, bEnd-ye-1) {
+				xe++
+				ye++
+			}
+			vb[offset+c] = xe
+
+			k := delta - c
+			if !odd && k >= -D && k <= D && xe+vf[offset+k] >= n {
+				return aEnd - xe, bEnd - ye, aEnd - xs, bEnd - ys
+			}
+		}
+	}
+	panic("no middle snake found")
+}
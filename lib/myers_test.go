@@ -0,0 +1,92 @@
+package delta
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMyersDiff(t *testing.T) {
+	d := NewMyersDiffer(
+		strings.Split("a b c a b b a", " "),
+		strings.Split("c b a b a c", " "),
+	).Solve()
+
+	a, b, edits := []string{}, []string{}, 0
+	for _, l := range d.TypedLines() {
+		switch l.Source {
+		case LineFromA:
+			a = append(a, l.A)
+			edits++
+		case LineFromB:
+			b = append(b, l.B)
+			edits++
+		case LineFromBoth:
+			a = append(a, l.A)
+			b = append(b, l.B)
+		}
+	}
+	if strings.Join(a, " ") != "a b c a b b a" || strings.Join(b, " ") != "c b a b a c" {
+		t.Errorf("solution does not reconstruct inputs: %v %v", a, b)
+	}
+	// the example from Myers' paper has an edit distance of 5
+	if edits != 5 {
+		t.Errorf("expected 5 edits but got %d: %+v", edits, d.Lines)
+	}
+}
+
+// lcsLength computes the length of the longest common subsequence of a and b.
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else if prev[j+1] > cur[j] {
+				cur[j+1] = prev[j+1]
+			} else {
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestMyersDiffMinimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	words := []string{"a", "b", "c", "d"}
+	randomLines := func() []string {
+		l := make([]string, r.Intn(30))
+		for i := range l {
+			l[i] = words[r.Intn(len(words))]
+		}
+		return l
+	}
+
+	for i := 0; i < 200; i++ {
+		a, b := randomLines(), randomLines()
+		d := NewMyersDiffer(a, b).Solve()
+		ra, rb, matches := []string{}, []string{}, 0
+		for _, l := range d.TypedLines() {
+			switch l.Source {
+			case LineFromA:
+				ra = append(ra, l.A)
+			case LineFromB:
+				rb = append(rb, l.B)
+			case LineFromBoth:
+				ra = append(ra, l.A)
+				rb = append(rb, l.B)
+				matches++
+			}
+		}
+		if !reflect.DeepEqual(ra, a) && len(a) > 0 || !reflect.DeepEqual(rb, b) && len(b) > 0 {
+			t.Fatalf("solution does not reconstruct inputs %v %v: %+v", a, b, d.Lines)
+		}
+		if lcs := lcsLength(a, b); matches != lcs {
+			t.Fatalf("expected %d matches for %v %v but got %d", lcs, a, b, matches)
+		}
+	}
+}
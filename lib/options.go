@@ -0,0 +1,204 @@
+package delta
+
+import (
+	"context"
+	"strings"
+)
+
+// Option configures Diff.
+type Option func(*options)
+
+type options struct {
+	algorithm   string
+	whitespace  WhitespaceOptions
+	equal       func(a, b string) bool
+	key         func(s string) string
+	weights     *Weights
+	tokenizer   func(string) []string
+	postProcess bool
+	indent      bool
+	detectMoves bool
+	ctx         context.Context
+}
+
+func defaultOptions() *options {
+	return &options{
+		algorithm:   "histogram",
+		whitespace:  DefaultWhitespace,
+		tokenizer:   SplitLines,
+		postProcess: true,
+	}
+}
+
+// WithAlgorithm sets the registered algorithm used by Diff. The default is
+// histogram.
+func WithAlgorithm(name string) Option {
+	return func(o *options) { o.algorithm = name }
+}
+
+// WithWhitespace sets how whitespace is compared. The default is
+// DefaultWhitespace.
+func WithWhitespace(w WhitespaceOptions) Option {
+	return func(o *options) { o.whitespace = w }
+}
+
+// WithEqual sets custom functions used to decide whether two tokens are
+// equal, which replace the whitespace mode. Tokens are equal if they have
+// the same key and equal returns true, so each token is only compared with
+// the tokens with the same key, e.g.
+//
+//	delta.WithEqual(strings.EqualFold, strings.ToLower)
+//
+// equal must be an equivalence relation, i.e. if a equals b and b equals c
+// then a equals c. If key is nil, all tokens have the same key, so each
+// token is compared with every distinct token before it, which is slow for
+// large inputs.
+func WithEqual(equal func(a, b string) bool, key func(s string) string) Option {
+	if key == nil {
+		key = func(string) string { return "" }
+	}
+	return func(o *options) { o.equal, o.key = equal, key }
+}
+
+// WithWeights sets the weights used by algorithms which support them.
+func WithWeights(w Weights) Option {
+	return func(o *options) { o.weights = &w }
+}
+
+// WithTokenizer sets the function used to split the inputs into tokens.
+// The default is SplitLines; SplitWords, SplitChars or SplitGraphemes may
+// be used for intra-line diffs.
+func WithTokenizer(tokenize func(string) []string) Option {
+	return func(o *options) { o.tokenizer = tokenize }
+}
+
+// WithPostProcess sets whether the solution is post processed using
+// PostProcess, which slides each added or deleted region down over the
+// equal lines after it, for algorithms which support it. It is enabled by
+// default.
+func WithPostProcess(enabled bool) Option {
+	return func(o *options) { o.postProcess = enabled }
+}
+
+// WithIndentHeuristic sets whether the solution is post processed using
+// PostProcessIndent rather than PostProcess. Unlike PostProcess, this is
+// done for all algorithms, unless post processing is disabled by
+// WithPostProcess. It is disabled by default.
+func WithIndentHeuristic(enabled bool) Option {
+	return func(o *options) { o.indent = enabled }
+}
+
+// WithMoveDetection sets whether moved blocks are detected using
+// DetectMoves. It is disabled by default.
+func WithMoveDetection(enabled bool) Option {
+	return func(o *options) { o.detectMoves = enabled }
+}
+
+// WithContext sets a context which stops the diff early when it is done,
+// see ContextSolver.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) { o.ctx = ctx }
+}
+
+// SplitLines splits s into lines. It is the default tokenizer for Diff.
+func SplitLines(s string) []string {
+	return strings.Split(s, "\n")
+}
+
+// SplitWords splits s into words, each including the following
+// punctuation or whitespace character. This is how DiffLine splits lines.
+func SplitWords(s string) []string {
+	return splitLine(s)
+}
+
+// Diff diffs a and b as configured by opts, e.g.
+//
+//	d, err := delta.Diff(a, b, delta.WithAlgorithm("patience"))
+//
+// An error is returned if the algorithm is not registered. If the context
+// set by WithContext is done before the diff is complete, a coarse diff is
+// returned together with the context's error.
+func Diff(a, b string, opts ...Option) (*DiffSolution, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	f, ok := algorithms[o.algorithm]
+	if !ok {
+		_, err := Algorithm(o.algorithm)
+		return nil, err
+	}
+
+	at, bt := o.tokenizer(a), o.tokenizer(b)
+	w := o.whitespace
+	ak, bk := at, bt
+	if o.equal != nil {
+		// replace each token with the first equal token, so that the
+		// solvers can compare tokens exactly. Tokens are grouped by key.
+		classes := map[string][]string{}
+		key := func(tokens []string) []string {
+			keys := make([]string, len(tokens))
+		next:
+			for i, t := range tokens {
+				k := o.key(t)
+				for _, c := range classes[k] {
+					if o.equal(c, t) {
+						keys[i] = c
+						continue next
+					}
+				}
+				keys[i] = t
+				classes[k] = append(classes[k], t)
+			}
+			return keys
+		}
+		ak, bk = key(at), key(bt)
+		w.Mode = WhitespaceExact
+	}
+
+	solver := f(ak, bk, w)
+	if s, ok := solver.(WeightedSolver); ok && o.weights != nil {
+		s.SetWeights(*o.weights)
+	}
+	if s, ok := solver.(PostProcessSolver); ok {
+		s.SetPostProcess(o.postProcess && !o.indent)
+	}
+	var d *DiffSolution
+	var err error
+	if o.ctx != nil {
+		d, err = SolveContext(o.ctx, solver)
+		if d == nil {
+			return nil, err
+		}
+	} else {
+		d = solver.Solve()
+	}
+
+	if o.equal != nil {
+		d = restoreTokens(d, at, bt)
+	}
+	if o.postProcess && o.indent {
+		d.PostProcessIndent()
+	}
+	if o.detectMoves {
+		d.DetectMoves()
+	}
+	return d, err
+}
+
+// restoreTokens replaces the tokens in d with the original tokens a and b.
+func restoreTokens(d *DiffSolution, a, b []string) *DiffSolution {
+	lines := d.TypedLines()
+	ai, bi := 0, 0
+	for i, l := range lines {
+		if l.InA() {
+			lines[i].A = a[ai]
+			ai++
+		}
+		if l.InB() {
+			lines[i].B = b[bi]
+			bi++
+		}
+	}
+	return NewDiffSolution(lines)
+}
@@ -0,0 +1,43 @@
+package delta
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffOptions(t *testing.T) {
+	a := "a\nB\nc\nd"
+	b := "A\nb\nc\ne"
+
+	// case-insensitive comparison, the original lines are kept
+	for _, name := range Algorithms() {
+		// without a key function, all tokens are compared
+		for _, key := range []func(string) string{strings.ToLower, nil} {
+			d, err := Diff(a, b, WithAlgorithm(name), WithEqual(strings.EqualFold, key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := []Line{
+				{A: "a", B: "A", Source: LineFromBoth, ALine: 1, BLine: 1},
+				{A: "B", B: "b", Source: LineFromBoth, ALine: 2, BLine: 2},
+				{A: "c", B: "c", Source: LineFromBoth, ALine: 3, BLine: 3},
+			}
+			if l := d.TypedLines()[:3]; !reflect.DeepEqual(l, e) {
+				t.Errorf("%s: expected:\n%+v\nbut got:\n%+v", name, e, l)
+			}
+		}
+	}
+
+	d, err := Diff("the cat sat", "the dog sat", WithTokenizer(SplitWords))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Lines) != 3 || d.Lines[1] != [3]string{"cat ", "dog ", "~"} {
+		t.Errorf("unexpected word diff: %+v", d.Lines)
+	}
+
+	if _, err := Diff(a, b, WithAlgorithm("unknown")); err == nil {
+		t.Errorf("expected error for unknown algorithm")
+	}
+}
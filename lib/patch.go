@@ -0,0 +1,365 @@
+package delta
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileStatus indicates how a file was changed in a patch.
+type FileStatus string
+
+// These are valid values for FileStatus.
+const (
+	FileModified FileStatus = "modified"
+	FileAdded    FileStatus = "added"
+	FileDeleted  FileStatus = "deleted"
+	FileRenamed  FileStatus = "renamed"
+	FileCopied   FileStatus = "copied"
+)
+
+// DevNull is the name used for the missing side of an added or deleted file.
+const DevNull = "/dev/null"
+
+// FilePatch is the diff of a single file, parsed from a patch by ParsePatch.
+type FilePatch struct {
+	// OldName and NewName are the paths of the file before and after the
+	// change, without git's a/ and b/ prefixes. The name is DevNull if the
+	// file was added or deleted.
+	OldName, NewName string
+
+	// OldMode and NewMode are the file modes, if present in the patch.
+	OldMode, NewMode string
+
+	Status FileStatus
+
+	// Similarity is the similarity index (in percent) of a rename or copy.
+	Similarity int
+
+	// Binary is true if the file is binary, in which case there are no hunks.
+	Binary bool
+
+	// OldNoNewline and NewNoNewline are true if the file is missing a
+	// newline at the end of the file before and after the change.
+	OldNoNewline, NewNoNewline bool
+
+	Hunks []Hunk
+}
+
+// Solution returns a DiffSolution containing the lines of all hunks. Lines
+// between hunks are not part of the patch, so they are omitted; use the
+// line numbers in Hunks to locate the lines in the original files.
+func (f *FilePatch) Solution() *DiffSolution {
+	lines := []Line{}
+	for _, h := range f.Hunks {
+		lines = append(lines, h.Lines...)
+	}
+	return NewDiffSolution(lines)
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParsePatch parses unified diff or git diff output, which may contain
+// multiple files, and returns a FilePatch for each file. Any text which is
+// not part of a diff, such as a commit message, is ignored.
+func ParsePatch(r io.Reader) ([]*FilePatch, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &patchParser{lines: lines}
+	return p.parse()
+}
+
+func readLines(r io.Reader) ([]string, error) {
+	lines := []string{}
+	br := bufio.NewReader(r)
+	for {
+		l, err := br.ReadString('\n')
+		if l != "" {
+			lines = append(lines, strings.TrimSuffix(l, "\n"))
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+type patchParser struct {
+	lines []string
+	i     int // index of the next line to parse
+}
+
+func (p *patchParser) peek(prefix string) bool {
+	return p.i < len(p.lines) && strings.HasPrefix(p.lines[p.i], prefix)
+}
+
+func (p *patchParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("delta: line %d: %s", p.i+1, fmt.Sprintf(format, args...))
+}
+
+func (p *patchParser) parse() ([]*FilePatch, error) {
+	files := []*FilePatch{}
+	var f *FilePatch
+	for p.i < len(p.lines) {
+		switch {
+		case p.peek("diff --git "):
+			f = &FilePatch{Status: FileModified}
+			files = append(files, f)
+			p.parseGitHeader(f)
+		case p.peek("--- ") && p.i+1 < len(p.lines) && strings.HasPrefix(p.lines[p.i+1], "+++ "):
+			f = &FilePatch{Status: FileModified}
+			files = append(files, f)
+			p.parseFileNames(f, false)
+		case p.peek("@@ ") && f != nil:
+			if err := p.parseHunk(f); err != nil {
+				return nil, err
+			}
+		default:
+			p.i++
+		}
+	}
+	return files, nil
+}
+
+// parseGitHeader parses the "diff --git" line and the extended header lines
+// which follow it.
+func (p *patchParser) parseGitHeader(f *FilePatch) {
+	f.OldName, f.NewName = parseGitNames(strings.TrimPrefix(p.lines[p.i], "diff --git "))
+	for p.i++; p.i < len(p.lines); p.i++ {
+		l := p.lines[p.i]
+		switch {
+		case strings.HasPrefix(l, "old mode "):
+			f.OldMode = strings.TrimPrefix(l, "old mode ")
+		case strings.HasPrefix(l, "new mode "):
+			f.NewMode = strings.TrimPrefix(l, "new mode ")
+		case strings.HasPrefix(l, "deleted file mode "):
+			f.Status = FileDeleted
+			f.OldMode = strings.TrimPrefix(l, "deleted file mode ")
+			f.NewName = DevNull
+		case strings.HasPrefix(l, "new file mode "):
+			f.Status = FileAdded
+			f.NewMode = strings.TrimPrefix(l, "new file mode ")
+			f.OldName = DevNull
+		case strings.HasPrefix(l, "similarity index "):
+			f.Similarity, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(l, "similarity index "), "%"))
+		case strings.HasPrefix(l, "dissimilarity index "):
+		case strings.HasPrefix(l, "rename from "):
+			f.Status = FileRenamed
+			f.OldName = unquoteName(strings.TrimPrefix(l, "rename from "))
+		case strings.HasPrefix(l, "rename to "):
+			f.Status = FileRenamed
+			f.NewName = unquoteName(strings.TrimPrefix(l, "rename to "))
+		case strings.HasPrefix(l, "copy from "):
+			f.Status = FileCopied
+			f.OldName = unquoteName(strings.TrimPrefix(l, "copy from "))
+		case strings.HasPrefix(l, "copy to "):
+			f.Status = FileCopied
+			f.NewName = unquoteName(strings.TrimPrefix(l, "copy to "))
+		case strings.HasPrefix(l, "index "):
+			// the mode is included if it is unchanged
+			if fields := strings.Fields(l); len(fields) == 3 && f.OldMode == "" {
+				f.OldMode, f.NewMode = fields[2], fields[2]
+			}
+		case strings.HasPrefix(l, "Binary files "):
+			f.Binary = true
+		case l == "GIT binary patch":
+			f.Binary = true
+			for p.i+1 < len(p.lines) && !strings.HasPrefix(p.lines[p.i+1], "diff --git ") {
+				p.i++
+			}
+		case strings.HasPrefix(l, "--- "):
+			p.parseFileNames(f, true)
+			return
+		default:
+			return
+		}
+	}
+}
+
+// parseFileNames parses the "---" and "+++" lines.
+func (p *patchParser) parseFileNames(f *FilePatch, git bool) {
+	oldName := parseFileName(strings.TrimPrefix(p.lines[p.i], "--- "), "a/", git)
+	p.i++
+	if !p.peek("+++ ") {
+		return
+	}
+	newName := parseFileName(strings.TrimPrefix(p.lines[p.i], "+++ "), "b/", git)
+	p.i++
+
+	switch {
+	case oldName == DevNull:
+		f.Status = FileAdded
+	case newName == DevNull:
+		f.Status = FileDeleted
+	}
+	// renames and copies are more accurately described by the git header
+	if f.Status != FileRenamed && f.Status != FileCopied {
+		f.OldName, f.NewName = oldName, newName
+	}
+}
+
+// parseFileName parses the name in a "---" or "+++" line, removing any
+// timestamp and, for git diffs, the given a/ or b/ prefix.
+func parseFileName(s, prefix string, git bool) string {
+	if i := strings.IndexByte(s, '\t'); i != -1 {
+		s = s[:i]
+	}
+	s = unquoteName(strings.TrimRight(s, " "))
+	if git && s != DevNull {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	return s
+}
+
+// parseGitNames parses the names in a "diff --git a/x b/y" line. Names
+// containing " b/" are ambiguous, so the names from the extended header or
+// the "---" and "+++" lines are preferred if present.
+func parseGitNames(s string) (string, string) {
+	if strings.HasPrefix(s, `"`) {
+		if i := strings.Index(s[1:], `" `); i != -1 {
+			a := unquoteName(s[:i+2])
+			b := unquoteName(strings.TrimSpace(s[i+2:]))
+			return strings.TrimPrefix(a, "a/"), strings.TrimPrefix(b, "b/")
+		}
+	}
+	// for an unchanged name, the line is "a/x b/x"
+	if n := len(s); n >= 5 && n%2 == 1 && s[n/2:n/2+3] == " b/" && s[:n/2] == "a/"+s[n/2+3:] {
+		return s[2 : n/2], s[n/2+3:]
+	}
+	if i := strings.Index(s, " b/"); i != -1 {
+		return strings.TrimPrefix(s[:i], "a/"), s[i+3:]
+	}
+	return s, s
+}
+
+// unquoteName unquotes names which git quotes because they contain special
+// characters.
+func unquoteName(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	return s
+}
+
+// ParseHunkHeader parses a hunk header of the form "@@ -l,s +l,s @@",
+// returning the start lines and lengths of the hunk in A and B. ok is false
+// if the line is not a valid hunk header.
+func ParseHunkHeader(line string) (aStart, aLen, bStart, bLen int, ok bool) {
+	m := hunkHeader.FindStringSubmatch(line)
+	if m == nil {
+		return 0, 0, 0, 0, false
+	}
+	aLen, bLen = 1, 1
+	aStart, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		aLen, _ = strconv.Atoi(m[2])
+	}
+	bStart, _ = strconv.Atoi(m[3])
+	if m[4] != "" {
+		bLen, _ = strconv.Atoi(m[4])
+	}
+	return aStart, aLen, bStart, bLen, true
+}
+
+// parseHunk parses a hunk, starting with the "@@" line.
+func (p *patchParser) parseHunk(f *FilePatch) error {
+	h := Hunk{}
+	var ok bool
+	h.AStart, h.ALen, h.BStart, h.BLen, ok = ParseHunkHeader(p.lines[p.i])
+	if !ok {
+		return p.errorf("invalid hunk header %q", p.lines[p.i])
+	}
+	p.i++
+
+	ai, bi := h.AStart, h.BStart
+	if h.ALen == 0 {
+		ai++
+	}
+	if h.BLen == 0 {
+		bi++
+	}
+	aRemaining, bRemaining := h.ALen, h.BLen
+	for aRemaining > 0 || bRemaining > 0 || p.peek(`\`) {
+		if p.i >= len(p.lines) {
+			return p.errorf("unexpected end of hunk")
+		}
+		l := p.lines[p.i]
+		if l == "" {
+			// some tools strip the trailing space of empty context lines
+			l = " "
+		}
+		switch l[0] {
+		case ' ':
+			h.Lines = append(h.Lines, Line{A: l[1:], B: l[1:], Source: LineFromBoth, ALine: ai, BLine: bi})
+			ai++
+			bi++
+			aRemaining--
+			bRemaining--
+		case '-':
+			h.Lines = append(h.Lines, Line{A: l[1:], Source: LineFromA, ALine: ai})
+			ai++
+			aRemaining--
+		case '+':
+			h.Lines = append(h.Lines, Line{B: l[1:], Source: LineFromB, BLine: bi})
+			bi++
+			bRemaining--
+		case '\\':
+			// "\ No newline at end of file" applies to the previous line
+			if len(h.Lines) > 0 {
+				last := h.Lines[len(h.Lines)-1]
+				f.OldNoNewline = f.OldNoNewline || last.InA()
+				f.NewNoNewline = f.NewNoNewline || last.InB()
+			}
+		default:
+			return p.errorf("unexpected line in hunk %q", l)
+		}
+		if aRemaining < 0 || bRemaining < 0 {
+			return p.errorf("hunk is longer than its header")
+		}
+		p.i++
+	}
+	f.Hunks = append(f.Hunks, h)
+	return nil
+}
+
+// PairChanges marks deletions which are followed by the same number of
+// additions as edited lines (LineFromBothEdit), pairing them in order, so
+// that intra-line differences can be shown as with a DiffSolution computed
+// by SequenceDiffer. Other lines are unchanged.
+func PairChanges(lines []Line) []Line {
+	paired := []Line{}
+	for i := 0; i < len(lines); {
+		dels := 0
+		for i+dels < len(lines) && lines[i+dels].Source == LineFromA {
+			dels++
+		}
+		adds := 0
+		for i+dels+adds < len(lines) && lines[i+dels+adds].Source == LineFromB {
+			adds++
+		}
+		if dels == 0 || dels != adds {
+			n := max(dels+adds, 1)
+			paired = append(paired, lines[i:i+n]...)
+			i += n
+			continue
+		}
+		for j := 0; j < dels; j++ {
+			del, add := lines[i+j], lines[i+dels+j]
+			paired = append(paired, Line{
+				A: del.A, B: add.B, Source: LineFromBothEdit,
+				ALine: del.ALine, BLine: add.BLine,
+			})
+		}
+		i += dels + adds
+	}
+	return paired
+}
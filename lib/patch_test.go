@@ -0,0 +1,114 @@
+package delta
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testGitPatch = `commit message which is ignored
+
+diff --git a/main.go b/main.go
+index 3b18e51..a0a3e7b 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var x = 1
++var x = 2
+ 
+@@ -10,2 +10,3 @@ func main() {
+ 	a()
++	b()
+ }
+\ No newline at end of file
+diff --git a/old name.txt b/new name.txt
+similarity index 95%
+rename from old name.txt
+rename to new name.txt
+diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+diff --git a/added.txt b/added.txt
+new file mode 100644
+index 0000000..ce01362
+--- /dev/null
++++ b/added.txt
+@@ -0,0 +1 @@
++hello
+diff --git a/image.png b/image.png
+deleted file mode 100644
+index 4a5b2c1..0000000
+Binary files a/image.png and /dev/null differ
+`
+
+func TestParsePatch(t *testing.T) {
+	files, err := ParsePatch(strings.NewReader(testGitPatch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 5 {
+		t.Fatalf("expected 5 files but got %d", len(files))
+	}
+
+	e := &FilePatch{
+		OldName: "main.go", NewName: "main.go",
+		OldMode: "100644", NewMode: "100644",
+		Status:       FileModified,
+		NewNoNewline: true,
+		OldNoNewline: true,
+		Hunks: []Hunk{
+			{
+				AStart: 1, ALen: 3, BStart: 1, BLen: 3,
+				Lines: []Line{
+					{A: "package main", B: "package main", Source: LineFromBoth, ALine: 1, BLine: 1},
+					{A: "var x = 1", Source: LineFromA, ALine: 2},
+					{B: "var x = 2", Source: LineFromB, BLine: 2},
+					{A: "", B: "", Source: LineFromBoth, ALine: 3, BLine: 3},
+				},
+			},
+			{
+				AStart: 10, ALen: 2, BStart: 10, BLen: 3,
+				Lines: []Line{
+					{A: "\ta()", B: "\ta()", Source: LineFromBoth, ALine: 10, BLine: 10},
+					{B: "\tb()", Source: LineFromB, BLine: 11},
+					{A: "}", B: "}", Source: LineFromBoth, ALine: 11, BLine: 12},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(files[0], e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, files[0])
+	}
+
+	if f := files[1]; f.Status != FileRenamed || f.OldName != "old name.txt" || f.NewName != "new name.txt" || f.Similarity != 95 {
+		t.Errorf("expected rename but got %+v", f)
+	}
+	if f := files[2]; f.Status != FileModified || f.OldMode != "100644" || f.NewMode != "100755" {
+		t.Errorf("expected mode change but got %+v", f)
+	}
+	if f := files[3]; f.Status != FileAdded || f.OldName != DevNull || f.NewName != "added.txt" || len(f.Hunks) != 1 {
+		t.Errorf("expected added file but got %+v", f)
+	}
+	if f := files[4]; f.Status != FileDeleted || !f.Binary || f.OldName != "image.png" || f.NewName != DevNull {
+		t.Errorf("expected deleted binary file but got %+v", f)
+	}
+}
+
+func TestParsePatchUnified(t *testing.T) {
+	patch := "--- a.txt\t2016-01-01 00:00:00\n+++ b.txt\t2016-01-02 00:00:00\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"
+	files, err := ParsePatch(strings.NewReader(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].OldName != "a.txt" || files[0].NewName != "b.txt" {
+		t.Fatalf("unexpected files %+v", files)
+	}
+	if applied, err := Apply("a\nb", files[0].Solution()); err != nil || applied != "a\nc" {
+		t.Errorf("expected solution to apply but got %q, %v", applied, err)
+	}
+
+	if _, err := ParsePatch(strings.NewReader("--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n")); err == nil {
+		t.Error("expected error for truncated hunk")
+	}
+}
@@ -0,0 +1,219 @@
+package delta
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+func init() {
+	RegisterAlgorithm("patience", func(a, b []string, w WhitespaceOptions) Solver {
+		d := NewPatienceDiffer(a, b)
+		d.SetWhitespace(w)
+		return d
+	})
+}
+
+// PatienceDiff uses the patience diff algorithm to generate a line-based
+// diff between two strings.
+func PatienceDiff(a, b string) *DiffSolution {
+	aw := strings.Split(a, "\n")
+	bw := strings.Split(b, "\n")
+	d := NewPatienceDiffer(aw, bw)
+	d.SetWhitespace(DefaultWhitespace)
+	return d.Solve()
+}
+
+// PatienceDiffer implements the patience diff algorithm. Lines which occur
+// exactly once in both A and B are used as anchors: the longest sequence of
+// anchors which appear in the same order in A and B is matched, and the
+// regions between anchors are diffed recursively.
+type PatienceDiffer struct {
+	a []string
+	b []string
+
+	whitespace  WhitespaceOptions
+	weights     Weights
+	postProcess bool
+	cancel      *cancellation
+}
+
+// NewPatienceDiffer returns a PatienceDiffer which diffs the given sequence of words.
+func NewPatienceDiffer(a, b []string) *PatienceDiffer {
+	return &PatienceDiffer{a: a, b: b, weights: DefaultWeights, postProcess: true}
+}
+
+// SetWhitespace sets how whitespace is compared. By default lines are
+// compared exactly.
+func (p *PatienceDiffer) SetWhitespace(w WhitespaceOptions) {
+	p.whitespace = w
+}
+
+// SetWeights sets the weights used to diff regions without unique lines.
+func (p *PatienceDiffer) SetWeights(w Weights) {
+	p.weights = w
+}
+
+// SetPostProcess sets whether PostProcess is called on the solution, which
+// is the default.
+func (p *PatienceDiffer) SetPostProcess(enabled bool) {
+	p.postProcess = enabled
+}
+
+// SolveContext is like Solve, but stops early if ctx is done, returning a
+// coarse solution and ctx.Err().
+func (p *PatienceDiffer) SolveContext(ctx context.Context) (*DiffSolution, error) {
+	p.cancel = &cancellation{ctx: ctx}
+	defer func() { p.cancel = nil }()
+	s := p.Solve()
+	if p.cancel.stopped() {
+		return coarseSolution(p.a, p.b, p.whitespace), p.cancel.err
+	}
+	return s, nil
+}
+
+// Solve returns a DiffSolution.
+func (p *PatienceDiffer) Solve() *DiffSolution {
+	if p.whitespace.IgnoreBlankLines {
+		return solveWithoutBlankLines(p.a, p.b, p.whitespace, func(a, b []string) *DiffSolution {
+			d := NewPatienceDiffer(a, b)
+			d.SetWhitespace(p.whitespace.subregion())
+			d.SetWeights(p.weights)
+			d.SetPostProcess(p.postProcess)
+			d.cancel = p.cancel
+			return d.Solve()
+		})
+	}
+
+	a, b := p.whitespace.ids(p.a, p.b)
+	s := &script{}
+	solvePatience(s, a, b, lineOptions(p.a, p.b, p.weights, p.cancel))
+	d := s.solution(p.a, p.b)
+	if p.postProcess {
+		d.PostProcess()
+	}
+	return d
+}
+
+// patience implements the patience diff algorithm on token ids.
+type patience struct {
+	a []int
+	b []int
+	*solveOptions
+}
+
+// solvePatience adds the patience diff of the token ids a and b to s.
+func solvePatience(s *script, a, b []int, o *solveOptions) {
+	p := &patience{a: a, b: b, solveOptions: o}
+	p.solveRange(s, 0, len(a), 0, len(b))
+}
+
+func (p *patience) eq(aIdx, bIdx int) bool {
+	return p.a[aIdx] == p.b[bIdx]
+}
+
+// uniqueMatches returns the lines which occur exactly once in both of the
+// given regions of A and B, as pairs of line numbers ordered by line in A.
+func (p *patience) uniqueMatches(aStart, aEnd, bStart, bEnd int) [][2]int {
+	type occurrence struct {
+		aCount, bCount int
+		aIdx, bIdx     int
+	}
+	occurrences := map[int]*occurrence{}
+	for i := aStart; i < aEnd; i++ {
+		k := p.a[i]
+		o := occurrences[k]
+		if o == nil {
+			o = &occurrence{}
+			occurrences[k] = o
+		}
+		o.aCount++
+		o.aIdx = i
+	}
+	for i := bStart; i < bEnd; i++ {
+		if o := occurrences[p.b[i]]; o != nil {
+			o.bCount++
+			o.bIdx = i
+		}
+	}
+
+	matches := [][2]int{}
+	for _, o := range occurrences {
+		if o.aCount == 1 && o.bCount == 1 {
+			matches = append(matches, [2]int{o.aIdx, o.bIdx})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i][0] < matches[j][0] })
+	return matches
+}
+
+// longestIncreasingSubsequence returns the longest subsequence of matches
+// (which are ordered by line in A) which is also ordered by line in B,
+// using patience sorting.
+func longestIncreasingSubsequence(matches [][2]int) [][2]int {
+	// piles[i] is the index of the top card of pile i, and prev[j] is the
+	// index of the card on top of the previous pile when j was placed.
+	piles := []int{}
+	prev := make([]int, len(matches))
+	for j, m := range matches {
+		i := sort.Search(len(piles), func(i int) bool { return matches[piles[i]][1] > m[1] })
+		if i > 0 {
+			prev[j] = piles[i-1]
+		} else {
+			prev[j] = -1
+		}
+		if i == len(piles) {
+			piles = append(piles, j)
+		} else {
+			piles[i] = j
+		}
+	}
+	if len(piles) == 0 {
+		return nil
+	}
+
+	lis := make([][2]int, len(piles))
+	for i, j := len(piles)-1, piles[len(piles)-1]; i >= 0; i, j = i-1, prev[j] {
+		lis[i] = matches[j]
+	}
+	return lis
+}
+
+// solveRange appends the diff of a[aStart:aEnd] and b[bStart:bEnd] to s.
+func (p *patience) solveRange(s *script, aStart, aEnd, bStart, bEnd int) {
+	if p.cancel.done(aEnd - aStart + bEnd - bStart) {
+		return
+	}
+
+	// copy over shared prefix
+	prefix := 0
+	for aStart < aEnd && bStart < bEnd && p.eq(aStart, bStart) {
+		aStart++
+		bStart++
+		prefix++
+	}
+	s.add(LineFromBoth, prefix)
+
+	// find shared suffix, which is copied over at the end
+	suffix := 0
+	for aStart < aEnd-suffix && bStart < bEnd-suffix && p.eq(aEnd-suffix-1, bEnd-suffix-1) {
+		suffix++
+	}
+	aEnd -= suffix
+	bEnd -= suffix
+
+	anchors := longestIncreasingSubsequence(p.uniqueMatches(aStart, aEnd, bStart, bEnd))
+	if len(anchors) == 0 {
+		// no unique lines to anchor on, so use the standard differ
+		solveRegion(s, p.a[aStart:aEnd], p.b[bStart:bEnd], p.solveOptions)
+	} else {
+		for _, anchor := range anchors {
+			p.solveRange(s, aStart, anchor[0], bStart, anchor[1])
+			s.add(LineFromBoth, 1)
+			aStart, bStart = anchor[0]+1, anchor[1]+1
+		}
+		p.solveRange(s, aStart, aEnd, bStart, bEnd)
+	}
+
+	s.add(LineFromBoth, suffix)
+}
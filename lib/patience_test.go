@@ -0,0 +1,53 @@
+package delta
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLongestIncreasingSubsequence(t *testing.T) {
+	matches := [][2]int{{0, 3}, {1, 1}, {2, 4}, {3, 2}, {4, 5}, {5, 0}}
+	e := [][2]int{{1, 1}, {3, 2}, {4, 5}}
+	if lis := longestIncreasingSubsequence(matches); !reflect.DeepEqual(lis, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, lis)
+	}
+}
+
+func TestPatienceDiff(t *testing.T) {
+	a := strings.Join([]string{
+		"func a() {",
+		"  return 1",
+		"}",
+		"",
+		"func b() {",
+		"  return 2",
+		"}",
+	}, "\n")
+	b := strings.Join([]string{
+		"func b() {",
+		"  return 2",
+		"}",
+		"",
+		"func a() {",
+		"  return 1",
+		"}",
+	}, "\n")
+
+	e := NewDiffSolution([]Line{
+		{A: "func a() {", B: "", Source: LineFromA},
+		{A: "  return 1", B: "", Source: LineFromA},
+		{A: "}", B: "", Source: LineFromA},
+		{A: "", B: "", Source: LineFromA},
+		{A: "func b() {", B: "func b() {", Source: LineFromBoth},
+		{A: "  return 2", B: "  return 2", Source: LineFromBoth},
+		{A: "}", B: "}", Source: LineFromBoth},
+		{A: "", B: "", Source: LineFromB},
+		{A: "", B: "func a() {", Source: LineFromB},
+		{A: "", B: "  return 1", Source: LineFromB},
+		{A: "", B: "}", Source: LineFromB},
+	})
+	if d := PatienceDiff(a, b); !reflect.DeepEqual(d, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, d)
+	}
+}
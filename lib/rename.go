@@ -0,0 +1,202 @@
+package delta
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultRenameThreshold is the default minimum similarity for renames and
+// copies, matching git's default.
+const DefaultRenameThreshold = 50
+
+// RenameOptions configures DetectRenames.
+type RenameOptions struct {
+	// Threshold is the minimum similarity (in percent) for two files to be
+	// considered a rename or copy. If zero, DefaultRenameThreshold is used.
+	Threshold int
+
+	// Copies enables detection of copies, where an added file is similar
+	// to a file which still exists.
+	Copies bool
+
+	// Context is the number of lines of context in the hunks of the
+	// FilePatches created by DetectPatchRenames.
+	Context int
+}
+
+// RenameFile is a file which is considered for rename detection.
+type RenameFile struct {
+	Name    string
+	Content string
+}
+
+// Rename is an added file which was found to be a rename or copy of
+// another file.
+type Rename struct {
+	From, To   string
+	Similarity int // in percent
+	Copy       bool
+}
+
+// fileLines splits content into lines, without the empty line after the
+// final newline.
+func fileLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// Similarity returns the percentage of lines of the larger of a and b which
+// are part of the match regions found by the histogram differ.
+func Similarity(a, b string) int {
+	return similarity(fileLines(a), fileLines(b))
+}
+
+func similarity(a, b []string) int {
+	if equalLines(a, b) {
+		return 100
+	}
+	matched := 0
+	ak, bk := DefaultWhitespace.ids(a, b)
+	h := &histogram{a: ak, b: bk, solveOptions: &solveOptions{}}
+	for _, r := range h.solveRange(0, len(ak), 0, len(bk)) {
+		matched += r.length()
+	}
+	return matched * 100 / max(len(a), len(b))
+}
+
+// DetectRenames pairs added files with deleted files that have similar
+// contents. If copies are enabled, added files are also paired with the
+// sources, which are files that exist in both revisions, and with deleted
+// files which were already renamed. Each added file is paired with at most
+// one file, preferring the most similar ones. Empty files are not paired,
+// as in git, since they are all identical.
+func DetectRenames(deleted, added, sources []RenameFile, opts RenameOptions) []Rename {
+	threshold := opts.Threshold
+	if threshold == 0 {
+		threshold = DefaultRenameThreshold
+	}
+	if !opts.Copies {
+		sources = nil
+	}
+
+	type candidate struct {
+		from, to   int // from indexes deleted and then sources
+		similarity int
+	}
+	from := append(append([]RenameFile{}, deleted...), sources...)
+	fromLines := make([][]string, len(from))
+	for i, f := range from {
+		fromLines[i] = fileLines(f.Content)
+	}
+	candidates := []candidate{}
+	for ti, t := range added {
+		tl := fileLines(t.Content)
+		for fi, fl := range fromLines {
+			if len(tl) == 0 || len(fl) == 0 {
+				continue
+			}
+			// the similarity can be at most the ratio of the lengths
+			if min(len(fl), len(tl))*100 < threshold*max(len(fl), len(tl)) {
+				continue
+			}
+			if s := similarity(fl, tl); s >= threshold {
+				candidates = append(candidates, candidate{fi, ti, s})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+
+	pairs := map[int]Rename{}
+	renamed := map[int]bool{}
+	for _, c := range candidates {
+		if _, ok := pairs[c.to]; ok {
+			continue
+		}
+		r := Rename{From: from[c.from].Name, To: added[c.to].Name, Similarity: c.similarity}
+		if c.from >= len(deleted) || renamed[c.from] {
+			if !opts.Copies {
+				continue
+			}
+			r.Copy = true
+		}
+		renamed[c.from] = true
+		pairs[c.to] = r
+	}
+
+	renames := []Rename{}
+	for i := range added {
+		if r, ok := pairs[i]; ok {
+			renames = append(renames, r)
+		}
+	}
+	return renames
+}
+
+// DetectPatchRenames finds added files in the patch which are renames of
+// deleted files, and replaces each pair with a single FilePatch containing
+// the differences between them. Copies are not detected, since the patch
+// only contains the full contents of added and deleted files.
+func DetectPatchRenames(files []*FilePatch, opts RenameOptions) []*FilePatch {
+	opts.Copies = false
+	deleted, added := []RenameFile{}, []RenameFile{}
+	deletedFiles, addedFiles := map[string]*FilePatch{}, map[string]*FilePatch{}
+	for _, f := range files {
+		if f.Binary {
+			continue
+		}
+		a, b := f.Solution().sides()
+		switch f.Status {
+		case FileDeleted:
+			deleted = append(deleted, RenameFile{Name: f.OldName, Content: fileContent(a, f.OldNoNewline)})
+			deletedFiles[f.OldName] = f
+		case FileAdded:
+			added = append(added, RenameFile{Name: f.NewName, Content: fileContent(b, f.NewNoNewline)})
+			addedFiles[f.NewName] = f
+		}
+	}
+
+	replaced := map[*FilePatch]*FilePatch{}
+	for _, r := range DetectRenames(deleted, added, nil, opts) {
+		from, to := deletedFiles[r.From], addedFiles[r.To]
+		a, _ := from.Solution().sides()
+		_, b := to.Solution().sides()
+		d := NewHistogramDiffer(a, b).Solve()
+		replaced[from] = nil
+		replaced[to] = &FilePatch{
+			OldName:      from.OldName,
+			NewName:      to.NewName,
+			OldMode:      from.OldMode,
+			NewMode:      to.NewMode,
+			Status:       FileRenamed,
+			Similarity:   r.Similarity,
+			OldNoNewline: from.OldNoNewline,
+			NewNoNewline: to.NewNoNewline,
+			Hunks:        d.Hunks(opts.Context),
+		}
+	}
+
+	result := []*FilePatch{}
+	for _, f := range files {
+		r, ok := replaced[f]
+		switch {
+		case !ok:
+			result = append(result, f)
+		case r != nil:
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// fileContent joins the lines of a file, adding the final newline unless
+// noNewline is set.
+func fileContent(lines []string, noNewline bool) string {
+	if len(lines) == 0 || noNewline {
+		return strings.Join(lines, "\n")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,89 @@
+package delta
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectRenames(t *testing.T) {
+	content := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	deleted := []RenameFile{
+		{Name: "old.go", Content: content},
+		{Name: "other.go", Content: "x\ny\nz\n"},
+	}
+	added := []RenameFile{
+		{Name: "new.go", Content: strings.Replace(content, "c\n", "C\n", 1)},
+		{Name: "unrelated.go", Content: "1\n2\n3\n"},
+		{Name: "copy.go", Content: content},
+	}
+	sources := []RenameFile{{Name: "same.go", Content: "1\n2\n3\n4\n"}}
+
+	e := []Rename{{From: "old.go", To: "copy.go", Similarity: 100}}
+	if r := DetectRenames(deleted, added, sources, RenameOptions{}); !reflect.DeepEqual(r, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, r)
+	}
+
+	e = []Rename{
+		{From: "old.go", To: "new.go", Similarity: 90, Copy: true},
+		{From: "same.go", To: "unrelated.go", Similarity: 75, Copy: true},
+		{From: "old.go", To: "copy.go", Similarity: 100},
+	}
+	if r := DetectRenames(deleted, added, sources, RenameOptions{Copies: true}); !reflect.DeepEqual(r, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, r)
+	}
+
+	e = []Rename{{From: "old.go", To: "copy.go", Similarity: 100}}
+	if r := DetectRenames(deleted, added, sources, RenameOptions{Threshold: 95, Copies: true}); !reflect.DeepEqual(r, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, r)
+	}
+
+	// empty files are not renames or copies of each other
+	empty := []RenameFile{{Name: "a/__init__.py"}}
+	added = []RenameFile{{Name: "b/__init__.py"}, {Name: ".gitkeep"}}
+	if r := DetectRenames(empty, added, empty, RenameOptions{Copies: true}); len(r) != 0 {
+		t.Errorf("expected no renames of empty files but got:\n%+v", r)
+	}
+}
+
+func TestDetectPatchRenames(t *testing.T) {
+	patch := `diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1,4 +0,0 @@
+-a
+-b
+-c
+-d
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,4 @@
++a
++b
++C
++d
+`
+	files, err := ParsePatch(strings.NewReader(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = DetectPatchRenames(files, RenameOptions{Context: 1})
+	if len(files) != 1 {
+		t.Fatalf("expected a single file but got %d", len(files))
+	}
+	f := files[0]
+	if f.Status != FileRenamed || f.OldName != "old.txt" || f.NewName != "new.txt" || f.Similarity != 75 {
+		t.Errorf("unexpected rename: %+v", f)
+	}
+	e := []Hunk{{AStart: 2, ALen: 3, BStart: 2, BLen: 3, Lines: []Line{
+		{A: "b", B: "b", Source: LineFromBoth, ALine: 2, BLine: 2},
+		{A: "c", B: "C", Source: LineFromBothEdit, ALine: 3, BLine: 3},
+		{A: "d", B: "d", Source: LineFromBoth, ALine: 4, BLine: 4},
+	}}}
+	if !reflect.DeepEqual(f.Hunks, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, f.Hunks)
+	}
+}
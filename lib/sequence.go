@@ -1,138 +1,189 @@
 package delta
 
 import (
-	"bytes"
+	"context"
 	"math"
-	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
+func init() {
+	RegisterAlgorithm("sequence", func(a, b []string, w WhitespaceOptions) Solver {
+		d := NewSequenceDiffer(a, b)
+		d.SetWhitespace(w)
+		return d
+	})
+}
+
 // SequenceDiff two strings using dynamic programming and return a DiffSolution.
 func SequenceDiff(a, b string) *DiffSolution {
 	aw := strings.Split(a, "\n")
 	bw := strings.Split(b, "\n")
 	d := NewSequenceDiffer(aw, bw)
-	d.ignoreWhitespace = true
+	d.SetWhitespace(DefaultWhitespace)
 	return d.Solve()
 }
 
 func splitLine(s string) []string {
 	ws := []string{}
-	w := &bytes.Buffer{}
-	for _, r := range s {
-		_, err := w.WriteRune(r)
-		if err != nil {
-			panic(err)
-		}
+	start := 0
+	for i := 0; i < len(s); {
+		// invalid UTF-8 is decoded as utf8.RuneError, which ends a word,
+		// but the original bytes are kept
+		r, n := utf8.DecodeRuneInString(s[i:])
+		i += n
 		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
-			ws = append(ws, w.String())
-			w.Reset()
+			ws = append(ws, s[start:i])
+			start = i
 		}
 	}
-	if w.Len() != 0 {
-		ws = append(ws, w.String())
+	if start < len(s) {
+		ws = append(ws, s[start:])
 	}
 	return ws
 }
 
-// DiffLine diffs on words
+// DiffLine diffs on words, see DiffLineGranularity.
 func DiffLine(a, b string) *DiffSolution {
-	aw := splitLine(a)
-	bw := splitLine(b)
-	if len(aw)*len(bw) > 100000000 {
-		return nil
-	}
-	return NewSequenceDiffer(aw, bw).Solve()
+	return DiffLineGranularity(a, b, GranularityWord)
 }
 
+// DefaultCellBudget is the largest number of cells (lines of A times lines
+// of B) for which SequenceDiffer stores the full results matrix, which
+// takes one byte per cell. Larger inputs are solved in linear space using
+// Hirschberg's algorithm, which takes about twice as long.
+const DefaultCellBudget = 1 << 24
+
 // SequenceDiffer computes a diff using a dynamic programming algorithm.
 type SequenceDiffer struct {
-	a        []string
-	b        []string
-	ab       [][]int32      // a x b score matrix
-	solution [][]LineSource // a x b results matrix
+	a []string
+	b []string
 
-	ignoreWhitespace bool
-	weights          weights
+	whitespace WhitespaceOptions
+	weights    Weights
+	cellBudget int
+	cancel     *cancellation
 }
 
 // NewSequenceDiffer returns a new SequenceDiffer to compare two lists of strings.
 func NewSequenceDiffer(a, b []string) *SequenceDiffer {
-	ab := make([][]int32, len(a))
-	solution := make([][]LineSource, len(a))
-	for i := range ab {
-		ab[i] = make([]int32, len(b))
-		solution[i] = make([]LineSource, len(b))
-	}
 	return &SequenceDiffer{
-		a:        a,
-		b:        b,
-		ab:       ab,
-		weights:  defaultWeights,
-		solution: solution,
+		a:          a,
+		b:          b,
+		weights:    DefaultWeights,
+		cellBudget: DefaultCellBudget,
 	}
 }
 
-// weights for the algorithm
-type weights struct {
-	Deletion int32
-	Match    int32
-	Mismatch int32
-	NewMode  int32
+// SetWhitespace sets how whitespace is compared. By default lines are
+// compared exactly.
+func (d *SequenceDiffer) SetWhitespace(w WhitespaceOptions) {
+	d.whitespace = w
+}
+
+// SetWeights sets the weights used to score solutions.
+func (d *SequenceDiffer) SetWeights(w Weights) {
+	d.weights = w
 }
 
-var defaultWeights = weights{
-	Deletion: -2,
-	Match:    100, // we _really_ like matches
-	Mismatch: -1,
-	NewMode:  0,
+// SetCellBudget sets the largest number of cells for which the full results
+// matrix is stored, see DefaultCellBudget. The solution does not depend on
+// the budget.
+func (d *SequenceDiffer) SetCellBudget(cells int) {
+	d.cellBudget = cells
 }
 
-func (d *SequenceDiffer) isLineEqual(a, b string) bool {
-	if d.ignoreWhitespace {
-		return strings.TrimSpace(a) == strings.TrimSpace(b)
+// SolveContext is like Solve, but stops early if ctx is done, returning a
+// coarse solution and ctx.Err().
+func (d *SequenceDiffer) SolveContext(ctx context.Context) (*DiffSolution, error) {
+	d.cancel = &cancellation{ctx: ctx}
+	defer func() { d.cancel = nil }()
+	s := d.Solve()
+	if d.cancel.stopped() {
+		return coarseSolution(d.a, d.b, d.whitespace), d.cancel.err
 	}
-	return a == b
+	return s, nil
 }
 
 // Solve the diff using dyanmic programming.
 func (d *SequenceDiffer) Solve() *DiffSolution {
-	s := &DiffSolution{}
-	m := modeBeginning
+	if d.whitespace.IgnoreBlankLines {
+		return solveWithoutBlankLines(d.a, d.b, d.whitespace, func(a, b []string) *DiffSolution {
+			e := NewSequenceDiffer(a, b)
+			e.SetWhitespace(d.whitespace.subregion())
+			e.SetWeights(d.weights)
+			e.SetCellBudget(d.cellBudget)
+			e.cancel = d.cancel
+			return e.Solve()
+		})
+	}
 
-	// right only?
-	if len(d.a) == 1 && d.a[0] == "" {
-		for _, l := range d.b {
-			s.addLineB(l)
+	a, b := d.whitespace.ids(d.a, d.b)
+	o := lineOptions(d.a, d.b, d.weights, d.cancel)
+	o.cellBudget = d.cellBudget
+	s := &script{}
+	solveSequence(s, a, b, o)
+	return s.solution(d.a, d.b)
+}
+
+// sequence implements the dynamic programming algorithm on token ids.
+type sequence struct {
+	a      []int
+	b      []int
+	aStart int // index of a[0] in the script
+	bStart int // index of b[0] in the script
+	layers int // number of modes with separate scores, see layer
+	*solveOptions
+}
+
+// solveSequence adds the diff of the token ids a and b to s.
+func solveSequence(s *script, a, b []int, o *solveOptions) {
+	// right only? the empty line in a is matched with a trailing empty
+	// line in b, so that no lines are lost.
+	if len(a) == 1 && o.emptyA != nil && o.emptyA(s.a) && len(b) > 0 {
+		s.add(LineFromB, len(b)-1)
+		if o.emptyB(s.b) {
+			s.add(LineFromBoth, 1)
+		} else {
+			s.add(LineFromB, 1)
+			s.add(LineFromA, 1)
 		}
-		return s
+		return
 	}
 
 	// left only?
-	if len(d.b) == 1 && d.b[0] == "" {
-		for _, l := range d.a {
-			s.addLineA(l)
+	if len(b) == 1 && o.emptyB != nil && o.emptyB(s.b) && len(a) > 0 {
+		s.add(LineFromA, len(a)-1)
+		if o.emptyA(s.a) {
+			s.add(LineFromBoth, 1)
+		} else {
+			s.add(LineFromA, 1)
+			s.add(LineFromB, 1)
 		}
-		return s
+		return
 	}
 
+	d := &sequence{a: a, b: b, aStart: s.a, bStart: s.b, layers: 1, solveOptions: o}
+	if d.weights.modal() {
+		d.layers = int(modeMismatch) + 1
+	}
+	m := modeBeginning
+
 	// copy over shared prefix
 	var i int
-	for ; i < len(d.a) && i < len(d.b); i++ {
-		if !d.isLineEqual(d.a[i], d.b[i]) {
-			break
-		}
-		s.addLine(d.a[i], d.b[i], LineFromBoth)
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
 	}
+	s.add(LineFromBoth, i)
 	if i > 0 {
 		m = modeMatch
 	}
 
-	// compute optimal
-	_ = d.computeOptimal(i, i, m)
-	d.getSolution(s, i, i)
-	return s
+	// compute optimal. Lines after the end of a or b have a score of 0.
+	r := region{i, len(a), i, len(b)}
+	d.solve(s, r, m, d.scores(r.j1-r.j0+1), d.scores(r.i1-r.i0))
+	s.addRest(d.aStart+len(a), d.bStart+len(b))
 }
 
 type blockMode int
@@ -145,117 +196,270 @@ const (
 	modeMismatch
 )
 
-// computeOptimal computes the optimal (maximum) score, which
-// corresponds to the best diff
-func (d *SequenceDiffer) computeOptimal(ai, bi int, m blockMode) int32 {
-	// base case: no more lines to align
-	if ai > len(d.a)-1 || bi > len(d.b)-1 {
+// choice is the choice made for a cell of the score matrix. It is stored
+// instead of a LineSource to save memory.
+type choice uint8
+
+const (
+	choiceNone choice = iota
+	choiceA
+	choiceB
+	choiceBoth
+	choiceEdit
+)
+
+// region is the part of the score matrix with rows i0 to i1 and columns j0
+// to j1, excluding i1 and j1. The score of cell i, j is the optimal
+// (maximum) score of diffing a[i:] and b[j:]. The scores of row i1 and
+// column j1 are the boundary of the region.
+type region struct {
+	i0, i1, j0, j1 int
+}
+
+// layer returns the index of the scores for the given mode. If the score of
+// a line depends on the previous line (see Weights.modal), each mode has
+// separate scores; otherwise all modes share the same scores.
+func (d *sequence) layer(m blockMode) int {
+	if d.layers == 1 {
 		return 0
 	}
+	return int(m)
+}
+
+// scores returns zeroed scores of n cells for each layer.
+func (d *sequence) scores(n int) [][]int32 {
+	s := make([][]int32, d.layers)
+	for l := range s {
+		s[l] = make([]int32, n)
+	}
+	return s
+}
 
-	// return memoized result
-	if d.solution[ai][bi] != Unknown {
-		return d.ab[ai][bi]
+// slice returns the cells i to j of each layer of s.
+func slice(s [][]int32, i, j int) [][]int32 {
+	r := make([][]int32, len(s))
+	for l := range s {
+		r[l] = s[l][i:j]
 	}
+	return r
+}
+
+// best returns the optimal score and choice for a cell in mode m, given
+// whether the lines of the cell are equal and the scores of the cells
+// below (deleting a line from A), to the right (deleting a line from B)
+// and diagonally (aligning the lines).
+func (d *sequence) best(m blockMode, eq bool, down, right, diag int32) (int32, choice) {
 	// initialize score to infinity
-	d.ab[ai][bi] = -math.MaxInt32
+	best, c := int32(-math.MaxInt32), choiceNone
 
 	// case: skip a, addition in b (deletion in a)
-	if ai < len(d.a) {
-		s := d.computeOptimal(ai+1, bi, modeDeleteA)
-		s += d.weights.Deletion
-		if m != modeDeleteA {
-			s += d.weights.NewMode
-		}
-		if s >= d.ab[ai][bi] {
-			d.ab[ai][bi] = s
-			d.solution[ai][bi] = LineFromA
-		}
+	s := down + d.weights.Deletion
+	if m != modeDeleteA {
+		s += d.weights.NewMode + d.weights.GapOpen
+	}
+	if s >= best {
+		best, c = s, choiceA
 	}
 
 	// case: skip b, addition in a (deletion in b)
-	if bi < len(d.b) {
-		s := d.computeOptimal(ai, bi+1, modeDeleteB)
-		s += d.weights.Deletion
-		if m != modeDeleteB {
-			s += d.weights.NewMode
-		}
-		if s >= d.ab[ai][bi] {
-			d.ab[ai][bi] = s
-			d.solution[ai][bi] = LineFromB
-		}
+	s = right + d.weights.Deletion
+	if m != modeDeleteB {
+		s += d.weights.NewMode + d.weights.GapOpen
+	}
+	if s >= best {
+		best, c = s, choiceB
 	}
 
 	// align lines
-	if ai < len(d.a) && bi < len(d.b) {
-		var n blockMode
-		var s int32
-		if d.isLineEqual(d.a[ai], d.b[bi]) {
+	n, s, l := modeMismatch, diag+d.weights.Mismatch, choiceEdit
+	if eq {
+		n, s, l = modeMatch, diag+d.weights.Match, choiceBoth
+	}
+	if m != n {
+		s += d.weights.NewMode
+	}
+	if s >= best {
+		best, c = s, l
+	}
+	return best, c
+}
+
+// next returns the cell and mode after choosing c in cell i, j.
+func next(i, j int, c choice) (int, int, blockMode) {
+	switch c {
+	case choiceA:
+		return i + 1, j, modeDeleteA
+	case choiceB:
+		return i, j + 1, modeDeleteB
+	case choiceBoth:
+		return i + 1, j + 1, modeMatch
+	case choiceEdit:
+		return i + 1, j + 1, modeMismatch
+	}
+	panic("unset line")
+}
+
+// row computes the scores of row i of r from the scores below it and the
+// score of the boundary cell to its right. For each cell and layer, visit
+// is called with the choice after the cells to its right.
+func (d *sequence) row(r region, i int, below, cur [][]int32, right [][]int32, visit func(j, l int, c choice)) {
+	cols := r.j1 - r.j0
+	for l := range cur {
+		cur[l][cols] = right[l][i-r.i0]
+	}
+	down, rightL := d.layer(modeDeleteA), d.layer(modeDeleteB)
+	for j := r.j1 - 1; j >= r.j0; j-- {
+		k := j - r.j0
+		eq := d.a[i] == d.b[j]
+		n := modeMismatch
+		if eq {
 			n = modeMatch
-			s = d.weights.Match
-		} else {
-			n = modeMismatch
-			s = d.weights.Mismatch
 		}
-		s += d.computeOptimal(ai+1, bi+1, n)
-		if m != n {
-			s += d.weights.NewMode
-		}
-		if s >= d.ab[ai][bi] {
-			d.ab[ai][bi] = s
-			// todo: consolidate with n
-			if n == modeMatch {
-				d.solution[ai][bi] = LineFromBoth
-			} else {
-				d.solution[ai][bi] = LineFromBothEdit
+		for l := range cur {
+			score, c := d.best(blockMode(l), eq, below[down][k], cur[rightL][k+1], below[d.layer(n)][k+1])
+			cur[l][k] = score
+			if visit != nil {
+				visit(j, l, c)
 			}
 		}
 	}
-
-	return d.ab[ai][bi]
 }
 
-func (d *SequenceDiffer) debug() string {
-	o := ""
-	for _, l := range d.ab {
-		for _, score := range l {
-			o += " " + strconv.Itoa(int(score))
+// rows computes the scores of the rows of r from the bottom up, and returns
+// the scores of row i0.
+func (d *sequence) rows(r region, bottom, right [][]int32, visit func(i int, row [][]int32)) [][]int32 {
+	below, cur := d.scores(r.j1-r.j0+1), d.scores(r.j1-r.j0+1)
+	for l := range below {
+		copy(below[l], bottom[l])
+	}
+	for i := r.i1 - 1; i >= r.i0 && !d.cancel.done(r.j1-r.j0); i-- {
+		d.row(r, i, below, cur, right, nil)
+		if visit != nil {
+			visit(i, cur)
 		}
-		o += "\n"
+		below, cur = cur, below
 	}
-	return o
+	return below
 }
 
-// todo: second pass which minimizes number of change blocks?
-func (d *SequenceDiffer) getSolution(s *DiffSolution, a, b int) {
-	// iterate until no more string
-	for a < len(d.a) || b < len(d.b) {
-		// no more a
-		if a == len(d.a) {
-			s.addLineB(d.b[b])
-			b++
-			continue
-		}
+// solve adds the optimal lines of region r starting in mode m to s, given
+// the scores of the bottom and right boundaries of the region. It returns
+// the cell and mode where the solution leaves the region.
+func (d *sequence) solve(s *script, r region, m blockMode, bottom, right [][]int32) (int, int, blockMode) {
+	rows, cols := r.i1-r.i0, r.j1-r.j0
+	if rows == 0 || cols == 0 {
+		return r.i0, r.j0, m
+	}
+	if d.cancel.stopped() {
+		// the solution is discarded
+		return r.i1, r.j1, m
+	}
+	if rows*cols <= d.cellBudget || rows == 1 {
+		return d.solveMatrix(s, r, m, bottom, right)
+	}
 
-		// no more b
-		if b == len(d.b) {
-			s.addLineA(d.a[a])
-			a++
-			continue
+	// Hirschberg's algorithm: find where the solution crosses the middle
+	// row, then solve the regions above and below it.
+	mid := r.i0 + rows/2
+	lower := region{mid, r.i1, r.j0, r.j1}
+	middle := d.rows(lower, bottom, slice(right, mid-r.i0, rows), nil)
+	upper := region{r.i0, mid, r.j0, r.j1}
+	i, j, n := d.cross(upper, m, middle, right)
+	if d.cancel.stopped() {
+		// the solution is discarded
+		return r.i1, r.j1, m
+	}
+	if j == r.j1 {
+		// the solution leaves the region before the middle row
+		return d.solve(s, upper, m, middle, right)
+	}
+
+	// scores of the column to the right of the crossing above the middle row
+	upperRight := slice(right, 0, mid-r.i0)
+	if j+1 < r.j1 {
+		upperRight = d.scores(mid - r.i0)
+		d.rows(region{r.i0, mid, j + 1, r.j1}, slice(middle, j+1-r.j0, cols+1), right, func(i int, row [][]int32) {
+			for l := range row {
+				upperRight[l][i-r.i0] = row[l][0]
+			}
+		})
+	}
+	d.solve(s, region{r.i0, mid, r.j0, j + 1}, m, slice(middle, 0, j+1-r.j0+1), upperRight)
+	return d.solve(s, region{i, r.i1, j, r.j1}, n, slice(bottom, j-r.j0, cols+1), slice(right, mid-r.i0, rows))
+}
+
+// solveMatrix solves region r like solve, storing the choices for all cells.
+func (d *sequence) solveMatrix(s *script, r region, m blockMode, bottom, right [][]int32) (int, int, blockMode) {
+	cols := r.j1 - r.j0
+	choices := make([][]choice, d.layers)
+	for l := range choices {
+		choices[l] = make([]choice, (r.i1-r.i0)*cols)
+	}
+	below, cur := d.scores(cols+1), d.scores(cols+1)
+	for l := range below {
+		copy(below[l], bottom[l])
+	}
+	for i := r.i1 - 1; i >= r.i0; i-- {
+		if d.cancel.done(cols) {
+			return r.i1, r.j1, m
 		}
-		switch d.solution[a][b] {
-		case LineFromA:
-			s.addLineA(d.a[a])
-			a++
-		case LineFromB:
-			s.addLineB(d.b[b])
-			b++
-		case LineFromBoth, LineFromBothEdit:
-			s.addLine(d.a[a], d.b[b], d.solution[a][b])
-			a++
-			b++
-		default:
-			panic("unset line")
+		d.row(r, i, below, cur, right, func(j, l int, c choice) {
+			choices[l][(i-r.i0)*cols+j-r.j0] = c
+		})
+		below, cur = cur, below
+	}
+
+	i, j := r.i0, r.j0
+	for i < r.i1 && j < r.j1 {
+		c := choices[d.layer(m)][(i-r.i0)*cols+j-r.j0]
+		s.add(sources[c], 1)
+		i, j, m = next(i, j, c)
+	}
+	return i, j, m
+}
+
+// cross returns the cell and mode where the solution starting at i0, j0 in
+// mode m leaves region r, given the scores of its boundaries.
+func (d *sequence) cross(r region, m blockMode, bottom, right [][]int32) (int, int, blockMode) {
+	type exit struct {
+		i, j int
+		m    blockMode
+	}
+	cols := r.j1 - r.j0
+	exits := func() [][]exit {
+		e := make([][]exit, d.layers)
+		for l := range e {
+			e[l] = make([]exit, cols)
 		}
+		return e
 	}
+	below, cur := d.scores(cols+1), d.scores(cols+1)
+	for l := range below {
+		copy(below[l], bottom[l])
+	}
+	exitsBelow, exitsCur := exits(), exits()
+	for i := r.i1 - 1; i >= r.i0 && !d.cancel.done(cols); i-- {
+		d.row(r, i, below, cur, right, func(j, l int, c choice) {
+			ni, nj, n := next(i, j, c)
+			if ni == r.i1 || nj == r.j1 {
+				exitsCur[l][j-r.j0] = exit{ni, nj, n}
+			} else if ni == i {
+				exitsCur[l][j-r.j0] = exitsCur[d.layer(n)][nj-r.j0]
+			} else {
+				exitsCur[l][j-r.j0] = exitsBelow[d.layer(n)][nj-r.j0]
+			}
+		})
+		below, cur = cur, below
+		exitsBelow, exitsCur = exitsCur, exitsBelow
+	}
+	e := exitsBelow[d.layer(m)][0]
+	return e.i, e.j, e.m
+}
+
+// sources are the line sources of each choice.
+var sources = [...]LineSource{
+	choiceA:    LineFromA,
+	choiceB:    LineFromB,
+	choiceBoth: LineFromBoth,
+	choiceEdit: LineFromBothEdit,
 }
@@ -0,0 +1,41 @@
+package delta
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSequenceDifferCellBudget(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gen := func() []string {
+		lines := []string{}
+		for i := r.Intn(40); i > 0; i-- {
+			lines = append(lines, string(rune('a'+r.Intn(4))))
+		}
+		return lines
+	}
+	weights := []Weights{
+		DefaultWeights,
+		{Deletion: -2, Match: 100, Mismatch: -1, GapOpen: -10},
+		{Deletion: -3, Match: 5, Mismatch: -4, NewMode: -2},
+	}
+	for i := 0; i < 500; i++ {
+		a, b := gen(), gen()
+		w := weights[i%len(weights)]
+
+		d := NewSequenceDiffer(a, b)
+		d.SetWeights(w)
+		e := d.Solve()
+
+		// the solution must be the same in linear space
+		for _, budget := range []int{0, 10} {
+			d := NewSequenceDiffer(a, b)
+			d.SetWeights(w)
+			d.SetCellBudget(budget)
+			if s := d.Solve(); !reflect.DeepEqual(s, e) {
+				t.Fatalf("budget %d: expected:\n%+v\nbut got:\n%+v", budget, e.Lines, s.Lines)
+			}
+		}
+	}
+}
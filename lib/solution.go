@@ -10,15 +10,100 @@ const (
 	LineFromB        LineSource = ">"
 	LineFromBoth     LineSource = "="
 	LineFromBothEdit LineSource = "~"
+
+	// LineMovedFrom and LineMovedTo are lines which were deleted from A
+	// and added to B at another position. They are set by DetectMoves.
+	LineMovedFrom LineSource = "<<"
+	LineMovedTo   LineSource = ">>"
+
+	// LineIgnoredFromA and LineIgnoredFromB are blank lines which were
+	// deleted from A or added to B, but are not considered to be changes.
+	// They are set if WhitespaceOptions.IgnoreBlankLines is enabled.
+	LineIgnoredFromA LineSource = "<?"
+	LineIgnoredFromB LineSource = ">?"
 )
 
 // DiffSolution contains a set of lines, where each element of
 // lines comprises the left and right line, and whether the change
-// was from A or B.
+// was from A or B. TypedLines returns the lines as Line structs.
 type DiffSolution struct {
 	Lines [][3]string
 }
 
+// Line is a line of a DiffSolution.
+type Line struct {
+	A, B   string
+	Source LineSource
+
+	// ALine and BLine are the 1-indexed line numbers of the line in A and B,
+	// or 0 if the line is not in A or B respectively. They are set by
+	// TypedLines and ignored by SetLines.
+	ALine, BLine int
+}
+
+// InA returns true if the line is present in A.
+func (l Line) InA() bool {
+	switch l.Source {
+	case LineFromB, LineMovedTo, LineIgnoredFromB:
+		return false
+	}
+	return true
+}
+
+// InB returns true if the line is present in B.
+func (l Line) InB() bool {
+	switch l.Source {
+	case LineFromA, LineMovedFrom, LineIgnoredFromA:
+		return false
+	}
+	return true
+}
+
+// Changed returns true if the line differs between A and B. Lines which
+// are matched but not identical (e.g. differ in whitespace) are changed,
+// but ignored blank lines are not.
+func (l Line) Changed() bool {
+	switch l.Source {
+	case LineIgnoredFromA, LineIgnoredFromB:
+		return false
+	}
+	return l.Source != LineFromBoth || l.A != l.B
+}
+
+// NewDiffSolution returns a DiffSolution containing the given lines.
+func NewDiffSolution(lines []Line) *DiffSolution {
+	d := &DiffSolution{}
+	d.SetLines(lines)
+	return d
+}
+
+// TypedLines returns the lines of the solution annotated with their line numbers.
+func (d *DiffSolution) TypedLines() []Line {
+	lines := make([]Line, len(d.Lines))
+	ai, bi := 0, 0
+	for i, l := range d.Lines {
+		tl := Line{A: l[0], B: l[1], Source: LineSource(l[2])}
+		if tl.InA() {
+			ai++
+			tl.ALine = ai
+		}
+		if tl.InB() {
+			bi++
+			tl.BLine = bi
+		}
+		lines[i] = tl
+	}
+	return lines
+}
+
+// SetLines replaces the lines of the solution with the given lines.
+func (d *DiffSolution) SetLines(lines []Line) {
+	d.Lines = make([][3]string, len(lines))
+	for i, l := range lines {
+		d.Lines[i] = [3]string{l.A, l.B, string(l.Source)}
+	}
+}
+
 func (d *DiffSolution) addLineA(a string) {
 	d.addLine(a, "", LineFromA)
 }
@@ -31,10 +116,6 @@ func (d *DiffSolution) addLine(a, b string, l LineSource) {
 	d.Lines = append(d.Lines, [3]string{a, b, string(l)})
 }
 
-func (d *DiffSolution) addSolution(e *DiffSolution) {
-	d.Lines = append(d.Lines, e.Lines...)
-}
-
 // PostProcess loops over the solution. For each changed region, see if we can
 // move it forward. i.e. if we have the following changeset:
 // 	 a [b c d] b c
@@ -42,11 +123,24 @@ func (d *DiffSolution) addSolution(e *DiffSolution) {
 //   a b c [d b c]
 // this heuristic only moves additions or deletions (but never both in a move).
 func (d *DiffSolution) PostProcess() {
+	lines := d.TypedLines()
+	postProcess(lines, func(l, m Line, inA bool) bool {
+		if inA {
+			return l.A == m.A
+		}
+		return l.B == m.B
+	})
+	d.SetLines(lines)
+}
+
+// postProcess implements PostProcess, where eq compares the A (if inA) or
+// B sides of two lines.
+func postProcess(lines []Line, eq func(l, m Line, inA bool) bool) {
 	lastChangeStartIndex := -1
 	lastChangeType := Unknown
 	lastLineType := LineFromBoth
-	for i, word := range d.Lines {
-		currentLineType := LineSource(word[2])
+	for i, line := range lines {
+		currentLineType := line.Source
 		// we've reached the end of a region. Now we try find a section to move forward.
 		if currentLineType == LineFromBoth && currentLineType != lastLineType {
 			if lastChangeType != LineFromB && lastChangeType != LineFromA {
@@ -57,13 +151,13 @@ func (d *DiffSolution) PostProcess() {
 			// walk the change region to find a match
 			p1 := lastChangeStartIndex
 			p2 := i
-			for ((lastChangeType == LineFromA && d.Lines[p1][0] == d.Lines[p2][0]) ||
-				(lastChangeType == LineFromB && d.Lines[p1][1] == d.Lines[p2][1])) &&
-				LineSource(d.Lines[p2][2]) == LineFromBoth {
-				d.Lines[p1], d.Lines[p2] = d.Lines[p2], d.Lines[p1]
+			for ((lastChangeType == LineFromA && eq(lines[p1], lines[p2], true)) ||
+				(lastChangeType == LineFromB && eq(lines[p1], lines[p2], false))) &&
+				lines[p2].Source == LineFromBoth {
+				lines[p1], lines[p2] = lines[p2], lines[p1]
 				p1++
 				p2++
-				if p2 >= len(d.Lines) {
+				if p2 >= len(lines) {
 					break
 				}
 			}
@@ -6,31 +6,27 @@ import (
 )
 
 func TestPostProcessAdd(t *testing.T) {
-	d := &DiffSolution{
-		Lines: [][3]string{
-			{"A", "A", string(LineFromBoth)},
-			{"", "B", string(LineFromB)},
-			{"", "C", string(LineFromB)},
-			{"", "D", string(LineFromB)},
-			{"", "E", string(LineFromB)},
-			{"B", "B", string(LineFromBoth)},
-			{"C", "C", string(LineFromBoth)},
-			{"D", "D", string(LineFromBoth)},
-		},
-	}
+	d := NewDiffSolution([]Line{
+		{A: "A", B: "A", Source: LineFromBoth},
+		{A: "", B: "B", Source: LineFromB},
+		{A: "", B: "C", Source: LineFromB},
+		{A: "", B: "D", Source: LineFromB},
+		{A: "", B: "E", Source: LineFromB},
+		{A: "B", B: "B", Source: LineFromBoth},
+		{A: "C", B: "C", Source: LineFromBoth},
+		{A: "D", B: "D", Source: LineFromBoth},
+	})
 
-	e := &DiffSolution{
-		Lines: [][3]string{
-			{"A", "A", string(LineFromBoth)},
-			{"B", "B", string(LineFromBoth)},
-			{"C", "C", string(LineFromBoth)},
-			{"D", "D", string(LineFromBoth)},
-			{"", "E", string(LineFromB)},
-			{"", "B", string(LineFromB)},
-			{"", "C", string(LineFromB)},
-			{"", "D", string(LineFromB)},
-		},
-	}
+	e := NewDiffSolution([]Line{
+		{A: "A", B: "A", Source: LineFromBoth},
+		{A: "B", B: "B", Source: LineFromBoth},
+		{A: "C", B: "C", Source: LineFromBoth},
+		{A: "D", B: "D", Source: LineFromBoth},
+		{A: "", B: "E", Source: LineFromB},
+		{A: "", B: "B", Source: LineFromB},
+		{A: "", B: "C", Source: LineFromB},
+		{A: "", B: "D", Source: LineFromB},
+	})
 	d.PostProcess()
 	if !reflect.DeepEqual(d, e) {
 		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, d)
@@ -38,29 +34,25 @@ func TestPostProcessAdd(t *testing.T) {
 }
 
 func TestPostProcessDel(t *testing.T) {
-	d := &DiffSolution{
-		Lines: [][3]string{
-			{"A", "A", string(LineFromBoth)},
-			{"B", "", string(LineFromA)},
-			{"C", "", string(LineFromA)},
-			{"D", "", string(LineFromA)},
-			{"B", "B", string(LineFromBoth)},
-			{"C", "C", string(LineFromBoth)},
-			{"D", "D", string(LineFromBoth)},
-		},
-	}
+	d := NewDiffSolution([]Line{
+		{A: "A", B: "A", Source: LineFromBoth},
+		{A: "B", B: "", Source: LineFromA},
+		{A: "C", B: "", Source: LineFromA},
+		{A: "D", B: "", Source: LineFromA},
+		{A: "B", B: "B", Source: LineFromBoth},
+		{A: "C", B: "C", Source: LineFromBoth},
+		{A: "D", B: "D", Source: LineFromBoth},
+	})
 
-	e := &DiffSolution{
-		Lines: [][3]string{
-			{"A", "A", string(LineFromBoth)},
-			{"B", "B", string(LineFromBoth)},
-			{"C", "C", string(LineFromBoth)},
-			{"D", "D", string(LineFromBoth)},
-			{"B", "", string(LineFromA)},
-			{"C", "", string(LineFromA)},
-			{"D", "", string(LineFromA)},
-		},
-	}
+	e := NewDiffSolution([]Line{
+		{A: "A", B: "A", Source: LineFromBoth},
+		{A: "B", B: "B", Source: LineFromBoth},
+		{A: "C", B: "C", Source: LineFromBoth},
+		{A: "D", B: "D", Source: LineFromBoth},
+		{A: "B", B: "", Source: LineFromA},
+		{A: "C", B: "", Source: LineFromA},
+		{A: "D", B: "", Source: LineFromA},
+	})
 	d.PostProcess()
 	if !reflect.DeepEqual(d, e) {
 		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, d)
@@ -68,25 +60,21 @@ func TestPostProcessDel(t *testing.T) {
 }
 
 func TestPostProcessDel2(t *testing.T) {
-	d := &DiffSolution{
-		Lines: [][3]string{
-			{"A", "Q", string(LineFromBothEdit)},
-			{"B", "", string(LineFromA)},
-			{"C", "", string(LineFromA)},
-			{"B", "B", string(LineFromBoth)},
-			{"C", "C", string(LineFromBoth)},
-		},
-	}
+	d := NewDiffSolution([]Line{
+		{A: "A", B: "Q", Source: LineFromBothEdit},
+		{A: "B", B: "", Source: LineFromA},
+		{A: "C", B: "", Source: LineFromA},
+		{A: "B", B: "B", Source: LineFromBoth},
+		{A: "C", B: "C", Source: LineFromBoth},
+	})
 
-	e := &DiffSolution{
-		Lines: [][3]string{
-			{"A", "Q", string(LineFromBothEdit)},
-			{"B", "B", string(LineFromBoth)},
-			{"C", "C", string(LineFromBoth)},
-			{"B", "", string(LineFromA)},
-			{"C", "", string(LineFromA)},
-		},
-	}
+	e := NewDiffSolution([]Line{
+		{A: "A", B: "Q", Source: LineFromBothEdit},
+		{A: "B", B: "B", Source: LineFromBoth},
+		{A: "C", B: "C", Source: LineFromBoth},
+		{A: "B", B: "", Source: LineFromA},
+		{A: "C", B: "", Source: LineFromA},
+	})
 	d.PostProcess()
 	if !reflect.DeepEqual(d, e) {
 		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, d)
@@ -0,0 +1,12 @@
+void f(void)
+{
+	/*
+	 * Do a.
+	 */
+	a();
+
+	/*
+	 * Do b.
+	 */
+	b();
+}
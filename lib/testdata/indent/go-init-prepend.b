@@ -0,0 +1,9 @@
+package p
+
+func init() {
+	register("a")
+}
+
+func init() {
+	register("b")
+}
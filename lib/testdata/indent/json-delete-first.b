@@ -0,0 +1,5 @@
+[
+  {
+    "id": 2
+  }
+]
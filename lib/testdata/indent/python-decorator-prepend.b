@@ -0,0 +1,8 @@
+class A:
+    @property
+    def a(self):
+        return 0
+
+    @property
+    def b(self):
+        return 1
@@ -0,0 +1,8 @@
+var tests = []test{
+	{
+		in: 0,
+	},
+	{
+		in: 1,
+	},
+}
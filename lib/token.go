@@ -0,0 +1,182 @@
+package delta
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// solveOptions configures the algorithms which diff token ids. Tokens are
+// replaced by ids before diffing, where equal tokens have the same id.
+type solveOptions struct {
+	weights    Weights
+	cellBudget int
+	cancel     *cancellation
+
+	// emptyA and emptyB report whether the token at index i of A or B is an
+	// empty line, see solveSequence. They are nil unless diffing lines.
+	emptyA, emptyB func(i int) bool
+}
+
+// lineOptions returns the options used to diff the lines a and b.
+func lineOptions(a, b []string, w Weights, cancel *cancellation) *solveOptions {
+	return &solveOptions{
+		weights:    w,
+		cellBudget: DefaultCellBudget,
+		cancel:     cancel,
+		emptyA:     func(i int) bool { return a[i] == "" },
+		emptyB:     func(i int) bool { return b[i] == "" },
+	}
+}
+
+// tokenAlgorithm is an algorithm which diffs token ids, adding the edits
+// to s.
+type tokenAlgorithm struct {
+	solve       func(s *script, a, b []int, o *solveOptions)
+	postProcess bool
+}
+
+// tokenAlgorithms are the algorithms available to TokenDiffer.
+var tokenAlgorithms = map[string]tokenAlgorithm{
+	"histogram": {solveHistogram, true},
+	"myers":     {solveMyers, false},
+	"patience":  {solvePatience, true},
+	"sequence":  {solveSequence, false},
+}
+
+// TokenDiffer diffs sequences of tokens of any type, such as AST nodes,
+// CSV records or log events, and returns an edit script of index ranges.
+// The line-based differs are implemented using the same algorithms.
+type TokenDiffer[T any] struct {
+	a, b []T
+	ids  func() ([]int, []int)
+
+	algorithm   string
+	weights     Weights
+	postProcess bool
+}
+
+// NewTokenDiffer returns a TokenDiffer which compares tokens using equal.
+// Tokens which are equal must have the same hash.
+func NewTokenDiffer[T any](a, b []T, equal func(x, y T) bool, hash func(x T) uint64) *TokenDiffer[T] {
+	d := newTokenDiffer(a, b)
+	d.ids = func() ([]int, []int) {
+		// classes[h] are the ids of the distinct tokens with hash h, and
+		// tokens[id] is the first token with that id.
+		classes := map[uint64][]int{}
+		tokens := []T{}
+		ids := func(s []T) []int {
+			r := make([]int, len(s))
+		next:
+			for i, t := range s {
+				h := hash(t)
+				for _, id := range classes[h] {
+					if equal(tokens[id], t) {
+						r[i] = id
+						continue next
+					}
+				}
+				r[i] = len(tokens)
+				classes[h] = append(classes[h], len(tokens))
+				tokens = append(tokens, t)
+			}
+			return r
+		}
+		return ids(a), ids(b)
+	}
+	return d
+}
+
+// NewComparableDiffer returns a TokenDiffer which compares tokens using ==.
+func NewComparableDiffer[T comparable](a, b []T) *TokenDiffer[T] {
+	d := newTokenDiffer(a, b)
+	d.ids = func() ([]int, []int) {
+		tokens := map[T]int{}
+		ids := func(s []T) []int {
+			r := make([]int, len(s))
+			for i, t := range s {
+				id, ok := tokens[t]
+				if !ok {
+					id = len(tokens)
+					tokens[t] = id
+				}
+				r[i] = id
+			}
+			return r
+		}
+		return ids(a), ids(b)
+	}
+	return d
+}
+
+func newTokenDiffer[T any](a, b []T) *TokenDiffer[T] {
+	return &TokenDiffer[T]{
+		a:           a,
+		b:           b,
+		algorithm:   "histogram",
+		weights:     DefaultWeights,
+		postProcess: true,
+	}
+}
+
+// SetAlgorithm sets the algorithm used to diff the tokens, which is
+// histogram by default. Algorithms registered with RegisterAlgorithm only
+// support lines.
+func (d *TokenDiffer[T]) SetAlgorithm(name string) error {
+	if _, ok := tokenAlgorithms[name]; !ok {
+		names := []string{}
+		for name := range tokenAlgorithms {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown algorithm %q, valid algorithms are: %s",
+			name, strings.Join(names, ", "))
+	}
+	d.algorithm = name
+	return nil
+}
+
+// SetWeights sets the weights used by the sequence algorithm, which is
+// also used for the regions between matches by histogram and patience.
+func (d *TokenDiffer[T]) SetWeights(w Weights) {
+	d.weights = w
+}
+
+// SetPostProcess sets whether the edit script is post processed like
+// DiffSolution.PostProcess, for the algorithms which do so for lines.
+func (d *TokenDiffer[T]) SetPostProcess(enabled bool) {
+	d.postProcess = enabled
+}
+
+// Edits returns an edit script which turns the tokens of A into the tokens
+// of B.
+func (d *TokenDiffer[T]) Edits() []Edit {
+	edits, _ := d.solve(nil)
+	return edits
+}
+
+// EditsContext is like Edits, but stops early if ctx is done. In that case
+// it returns an edit script which only matches the common prefix and
+// suffix of the tokens, together with ctx.Err().
+func (d *TokenDiffer[T]) EditsContext(ctx context.Context) ([]Edit, error) {
+	return d.solve(&cancellation{ctx: ctx})
+}
+
+func (d *TokenDiffer[T]) solve(cancel *cancellation) ([]Edit, error) {
+	a, b := d.ids()
+	alg := tokenAlgorithms[d.algorithm]
+	s := &script{}
+	alg.solve(s, a, b, &solveOptions{
+		weights:    d.weights,
+		cellBudget: DefaultCellBudget,
+		cancel:     cancel,
+	})
+	if cancel.stopped() {
+		return coarseScript(a, b).edits, cancel.err
+	}
+	if d.postProcess && alg.postProcess {
+		s.postProcess(a, b)
+	}
+	return s.edits, nil
+}
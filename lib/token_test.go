@@ -0,0 +1,85 @@
+package delta
+
+import (
+	"context"
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenDiffer(t *testing.T) {
+	a := []int{1, 2, 3, 4, 5}
+	b := []int{1, 3, 4, 6, 5}
+	for name := range tokenAlgorithms {
+		d := NewComparableDiffer(a, b)
+		if err := d.SetAlgorithm(name); err != nil {
+			t.Fatal(err)
+		}
+		e := []Edit{
+			{Op: LineFromBoth, AStart: 0, AEnd: 1, BStart: 0, BEnd: 1},
+			{Op: LineFromA, AStart: 1, AEnd: 2, BStart: 1, BEnd: 1},
+			{Op: LineFromBoth, AStart: 2, AEnd: 4, BStart: 1, BEnd: 3},
+			{Op: LineFromB, AStart: 4, AEnd: 4, BStart: 3, BEnd: 4},
+			{Op: LineFromBoth, AStart: 4, AEnd: 5, BStart: 4, BEnd: 5},
+		}
+		if edits := d.Edits(); !reflect.DeepEqual(edits, e) {
+			t.Errorf("%s: expected:\n%+v\nbut got:\n%+v", name, e, edits)
+		}
+	}
+
+	if err := NewComparableDiffer(a, b).SetAlgorithm("unknown"); err == nil {
+		t.Errorf("expected error for unknown algorithm")
+	}
+}
+
+func TestTokenDifferEqual(t *testing.T) {
+	a := []string{"Foo", "bar", "baz"}
+	b := []string{"foo", "BAZ", "qux"}
+	hash := func(s string) uint64 {
+		h := fnv.New64()
+		h.Write([]byte(strings.ToLower(s)))
+		return h.Sum64()
+	}
+	d := NewTokenDiffer(a, b, strings.EqualFold, hash)
+	e := []Edit{
+		{Op: LineFromBoth, AStart: 0, AEnd: 1, BStart: 0, BEnd: 1},
+		{Op: LineFromA, AStart: 1, AEnd: 2, BStart: 1, BEnd: 1},
+		{Op: LineFromBoth, AStart: 2, AEnd: 3, BStart: 1, BEnd: 2},
+		{Op: LineFromB, AStart: 3, AEnd: 3, BStart: 2, BEnd: 3},
+	}
+	if edits := d.Edits(); !reflect.DeepEqual(edits, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, edits)
+	}
+
+	// the coarse edit script only matches the common prefix
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a = append(a, make([]string, checkInterval)...)
+	d = NewTokenDiffer(a, b, strings.EqualFold, hash)
+	d.SetAlgorithm("myers")
+	edits, err := d.EditsContext(ctx)
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled but got %v", err)
+	}
+	e = []Edit{
+		{Op: LineFromBoth, AStart: 0, AEnd: 1, BStart: 0, BEnd: 1},
+		{Op: LineFromA, AStart: 1, AEnd: len(a), BStart: 1, BEnd: 1},
+		{Op: LineFromB, AStart: len(a), AEnd: len(a), BStart: 1, BEnd: 3},
+	}
+	if !reflect.DeepEqual(edits, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, edits)
+	}
+}
+
+func TestDiffSolutionEdits(t *testing.T) {
+	d := HistogramDiff("a\nb\nc", "a\nB\nc")
+	e := []Edit{
+		{Op: LineFromBoth, AStart: 0, AEnd: 1, BStart: 0, BEnd: 1},
+		{Op: LineFromBothEdit, AStart: 1, AEnd: 2, BStart: 1, BEnd: 2},
+		{Op: LineFromBoth, AStart: 2, AEnd: 3, BStart: 2, BEnd: 3},
+	}
+	if edits := d.Edits(); !reflect.DeepEqual(edits, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, edits)
+	}
+}
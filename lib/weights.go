@@ -0,0 +1,78 @@
+package delta
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Weights are the scores used by SequenceDiffer to choose between deleting
+// a line and matching (or mismatching) a pair of lines. The solution with the
+// highest total score is chosen.
+//
+// If GapOpen is non-zero the gap penalty is affine: a run of n lines which
+// are only in A (or only in B) scores GapOpen + n*Deletion, so Deletion is
+// the gap extend penalty. A negative GapOpen prefers contiguous blocks of
+// changes over scattered edits.
+type Weights struct {
+	Deletion int32 // score for a line which is only in A or B
+	Match    int32 // score for a pair of equal lines
+	Mismatch int32 // score for a pair of lines which are edited
+	NewMode  int32 // score added whenever the kind of change differs from the previous line
+	GapOpen  int32 // score added whenever a run of lines only in A or B starts
+}
+
+// DefaultWeights are the weights used unless SetWeights is called.
+var DefaultWeights = Weights{
+	Deletion: -2,
+	Match:    100, // we _really_ like matches
+	Mismatch: -1,
+	NewMode:  0,
+	GapOpen:  0,
+}
+
+// weightNames are the names used by ParseWeights.
+var weightNames = []string{"deletion", "match", "mismatch", "new-mode", "gap-open", "gap-extend"}
+
+// modal returns true if the score of a line depends on the previous line.
+func (w Weights) modal() bool {
+	return w.NewMode != 0 || w.GapOpen != 0
+}
+
+// ParseWeights parses a comma separated list of name=value pairs, such as
+// "match=50,gap-open=-10", and returns w with those weights replaced. Valid
+// names are deletion, match, mismatch, new-mode, gap-open and gap-extend,
+// which is another name for deletion.
+func ParseWeights(s string, w Weights) (Weights, error) {
+	for _, kv := range strings.Split(s, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			return w, fmt.Errorf("invalid weight %q, expected name=value", kv)
+		}
+		name, value := strings.TrimSpace(kv[:i]), strings.TrimSpace(kv[i+1:])
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return w, fmt.Errorf("invalid value for weight %q: %v", name, err)
+		}
+		switch name {
+		case "deletion", "gap-extend":
+			w.Deletion = int32(v)
+		case "match":
+			w.Match = int32(v)
+		case "mismatch":
+			w.Mismatch = int32(v)
+		case "new-mode":
+			w.NewMode = int32(v)
+		case "gap-open":
+			w.GapOpen = int32(v)
+		default:
+			return w, fmt.Errorf("unknown weight %q, valid weights are: %s",
+				name, strings.Join(weightNames, ", "))
+		}
+	}
+	return w, nil
+}
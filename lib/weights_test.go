@@ -0,0 +1,49 @@
+package delta
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWeights(t *testing.T) {
+	w, err := ParseWeights("match=50, gap-open=-10,gap-extend=-3", DefaultWeights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := Weights{Deletion: -3, Match: 50, Mismatch: -1, NewMode: 0, GapOpen: -10}
+	if w != e {
+		t.Errorf("expected %+v but got %+v", e, w)
+	}
+	for _, s := range []string{"match", "match=x", "unknown=1"} {
+		if _, err := ParseWeights(s, DefaultWeights); err == nil {
+			t.Errorf("expected error parsing %q", s)
+		}
+	}
+}
+
+func TestAffineGaps(t *testing.T) {
+	a := []string{"a", "b", "c", "c"}
+	b := []string{"b", "a", "a", "b"}
+
+	// the additions are split into two blocks by default
+	e := [][3]string{
+		{"", "b", ">"}, {"a", "a", "="}, {"", "a", ">"}, {"b", "b", "="},
+		{"c", "", "<"}, {"c", "", "<"},
+	}
+	if s := NewSequenceDiffer(a, b).Solve(); !reflect.DeepEqual(s.Lines, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, s.Lines)
+	}
+
+	// opening a gap is expensive, so the additions are grouped together
+	e = [][3]string{
+		{"", "b", ">"}, {"", "a", ">"}, {"a", "a", "="}, {"b", "b", "="},
+		{"c", "", "<"}, {"c", "", "<"},
+	}
+	d := NewSequenceDiffer(a, b)
+	w := DefaultWeights
+	w.GapOpen = -10
+	d.SetWeights(w)
+	if s := d.Solve(); !reflect.DeepEqual(s.Lines, e) {
+		t.Errorf("expected:\n%+v\nbut got:\n%+v", e, s.Lines)
+	}
+}
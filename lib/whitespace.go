@@ -0,0 +1,159 @@
+package delta
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// WhitespaceMode controls how whitespace is compared when matching lines.
+type WhitespaceMode string
+
+// These are valid values for WhitespaceMode. The names match git's options.
+const (
+	// WhitespaceExact only matches identical lines.
+	WhitespaceExact WhitespaceMode = "exact"
+	// WhitespaceIgnoreSurrounding ignores leading and trailing whitespace.
+	WhitespaceIgnoreSurrounding WhitespaceMode = "ignore-surrounding-space"
+	// WhitespaceIgnoreTrailing ignores whitespace at the end of lines.
+	WhitespaceIgnoreTrailing WhitespaceMode = "ignore-trailing-space"
+	// WhitespaceIgnoreChange ignores trailing whitespace, and treats runs
+	// of whitespace as equal.
+	WhitespaceIgnoreChange WhitespaceMode = "ignore-space-change"
+	// WhitespaceIgnoreAll ignores all whitespace.
+	WhitespaceIgnoreAll WhitespaceMode = "ignore-all-space"
+)
+
+var whitespaceModes = []WhitespaceMode{
+	WhitespaceExact,
+	WhitespaceIgnoreSurrounding,
+	WhitespaceIgnoreTrailing,
+	WhitespaceIgnoreChange,
+	WhitespaceIgnoreAll,
+}
+
+// WhitespaceOptions configures how Solvers compare lines. The zero value
+// compares lines exactly.
+type WhitespaceOptions struct {
+	Mode WhitespaceMode
+
+	// IgnoreCRAtEOL ignores a carriage return at the end of lines, so that
+	// files with CRLF line endings can be compared with LF files.
+	IgnoreCRAtEOL bool
+
+	// IgnoreBlankLines only matches lines which are not blank. Blank lines
+	// are matched if possible, and are otherwise marked as LineIgnoredFromA
+	// and LineIgnoredFromB so that they are not shown as changes.
+	IgnoreBlankLines bool
+}
+
+// DefaultWhitespace is used by the line-based diff functions such as
+// HistogramDiff.
+var DefaultWhitespace = WhitespaceOptions{Mode: WhitespaceIgnoreSurrounding}
+
+// ParseWhitespaceMode returns the WhitespaceMode with the given name.
+func ParseWhitespaceMode(name string) (WhitespaceMode, error) {
+	names := []string{}
+	for _, m := range whitespaceModes {
+		if string(m) == name {
+			return m, nil
+		}
+		names = append(names, string(m))
+	}
+	return "", fmt.Errorf("unknown whitespace mode %q, valid modes are: %s",
+		name, strings.Join(names, ", "))
+}
+
+// key returns the text of a line which is compared when matching lines.
+func (w WhitespaceOptions) key(line string) string {
+	if w.IgnoreCRAtEOL {
+		line = strings.TrimSuffix(line, "\r")
+	}
+	switch w.Mode {
+	case WhitespaceIgnoreSurrounding:
+		return strings.TrimSpace(line)
+	case WhitespaceIgnoreTrailing:
+		return strings.TrimRightFunc(line, unicode.IsSpace)
+	case WhitespaceIgnoreChange:
+		fields := strings.Fields(line)
+		if len(fields) > 0 && strings.IndexFunc(line, unicode.IsSpace) == 0 {
+			return " " + strings.Join(fields, " ")
+		}
+		return strings.Join(fields, " ")
+	case WhitespaceIgnoreAll:
+		return strings.Join(strings.Fields(line), "")
+	}
+	return line
+}
+
+// ids returns the ids of the keys of the lines of a and b, so that lines
+// can be compared quickly. Lines have the same id if their keys are equal.
+func (w WhitespaceOptions) ids(a, b []string) ([]int, []int) {
+	ids := map[string]int{}
+	keys := func(lines []string) []int {
+		r := make([]int, len(lines))
+		for i, l := range lines {
+			k := w.key(l)
+			id, ok := ids[k]
+			if !ok {
+				id = len(ids)
+				ids[k] = id
+			}
+			r[i] = id
+		}
+		return r
+	}
+	return keys(a), keys(b)
+}
+
+// subregion returns the options used to solve part of the inputs, after
+// blank lines have been removed.
+func (w WhitespaceOptions) subregion() WhitespaceOptions {
+	w.IgnoreBlankLines = false
+	return w
+}
+
+// solveWithoutBlankLines diffs the lines of a and b which are not blank
+// using solve, then inserts the blank lines back into the solution. Blank
+// lines are matched if they are equal, and are otherwise marked as
+// LineIgnoredFromA or LineIgnoredFromB.
+func solveWithoutBlankLines(a, b []string, w WhitespaceOptions, solve func(a, b []string) *DiffSolution) *DiffSolution {
+	nonBlank := func(lines []string) []string {
+		r := []string{}
+		for _, l := range lines {
+			if strings.TrimSpace(l) != "" {
+				r = append(r, l)
+			}
+		}
+		return r
+	}
+
+	s := &DiffSolution{}
+	ai, bi := 0, 0
+	blankA := func() bool { return ai < len(a) && strings.TrimSpace(a[ai]) == "" }
+	blankB := func() bool { return bi < len(b) && strings.TrimSpace(b[bi]) == "" }
+	// addBlankLines adds the blank lines before the next line of A and/or B
+	addBlankLines := func(inA, inB bool) {
+		for ; inA && inB && blankA() && blankB() && w.key(a[ai]) == w.key(b[bi]); ai, bi = ai+1, bi+1 {
+			s.addLine(a[ai], b[bi], LineFromBoth)
+		}
+		for ; inA && blankA(); ai++ {
+			s.addLine(a[ai], "", LineIgnoredFromA)
+		}
+		for ; inB && blankB(); bi++ {
+			s.addLine("", b[bi], LineIgnoredFromB)
+		}
+	}
+	for _, l := range solve(nonBlank(a), nonBlank(b)).TypedLines() {
+		addBlankLines(l.InA(), l.InB())
+		s.addLine(l.A, l.B, l.Source)
+		if l.InA() {
+			ai++
+		}
+		if l.InB() {
+			bi++
+		}
+	}
+	addBlankLines(true, true)
+	return s
+}
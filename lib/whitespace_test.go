@@ -0,0 +1,55 @@
+package delta
+
+import (
+	"testing"
+)
+
+func TestWhitespaceKey(t *testing.T) {
+	lines := []string{"a b", " a b", "a b  ", "a  b", "ab", "\ta\tb"}
+	cases := map[WhitespaceMode][]bool{
+		WhitespaceExact:             {true, false, false, false, false, false},
+		WhitespaceIgnoreSurrounding: {true, true, true, false, false, false},
+		WhitespaceIgnoreTrailing:    {true, false, true, false, false, false},
+		WhitespaceIgnoreChange:      {true, false, true, true, false, false},
+		WhitespaceIgnoreAll:         {true, true, true, true, true, true},
+	}
+	for mode, e := range cases {
+		w := WhitespaceOptions{Mode: mode}
+		for i, l := range lines {
+			if eq := w.key(lines[0]) == w.key(l); eq != e[i] {
+				t.Errorf("%s: expected %q == %q to be %v", mode, lines[0], l, e[i])
+			}
+		}
+	}
+	// leading whitespace is not ignored by ignore-space-change
+	w := WhitespaceOptions{Mode: WhitespaceIgnoreChange}
+	if w.key(" a b") != w.key("\t a  b ") {
+		t.Errorf("expected runs of leading whitespace to be equal")
+	}
+
+	w = WhitespaceOptions{Mode: WhitespaceExact, IgnoreCRAtEOL: true}
+	if w.key("a\r") != w.key("a") || w.key("a \r") == w.key("a") {
+		t.Errorf("expected only carriage returns at the end of lines to be ignored")
+	}
+}
+
+func TestIgnoreBlankLines(t *testing.T) {
+	a := "a\nb\n\nc\nd\n"
+	b := "a\n\nb\nc\nd\ne\n"
+	for _, name := range Algorithms() {
+		diff, _ := AlgorithmWhitespace(name, WhitespaceOptions{IgnoreBlankLines: true})
+		d := diff(a, b)
+		changed := []Line{}
+		for _, l := range d.TypedLines() {
+			if l.Changed() {
+				changed = append(changed, l)
+			}
+		}
+		if len(changed) != 1 || changed[0].Source != LineFromB || changed[0].B != "e" {
+			t.Errorf("%s: expected only e to be changed, but got %+v", name, changed)
+		}
+		if r, err := Apply(a, d); err != nil || r != b {
+			t.Errorf("%s: unexpected result %q: %v", name, r, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/octavore/delta/lib"
+)
+
+// exit codes for merges, compatible with git mergetool
+const (
+	mergeExitClean     = 0
+	mergeExitConflicts = 1
+	mergeExitError     = 2
+)
+
+// runMerge merges the changes from pathBase to pathLocal and pathRemote, and
+// writes the result to pathMerged. It returns the exit code.
+func runMerge(pathBase, pathLocal, pathRemote, pathMerged string) int {
+	style := delta.ConflictStyle(*conflictStyle)
+	if style != delta.ConflictStyleMerge && style != delta.ConflictStyleDiff3 {
+		fmt.Fprintf(os.Stderr, "unknown conflict style %q\n", *conflictStyle)
+		return mergeExitError
+	}
+
+	contents := []string{}
+	for _, path := range []string{pathBase, pathLocal, pathRemote} {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading %q: %v\n", path, err)
+			return mergeExitError
+		}
+		contents = append(contents, string(b))
+	}
+
+	merged, conflicts := delta.Merge(contents[0], contents[1], contents[2], delta.MergeOptions{
+		Style:      style,
+		LocalName:  pathLocal,
+		BaseName:   pathBase,
+		RemoteName: pathRemote,
+	})
+	err := ioutil.WriteFile(pathMerged, []byte(merged), 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error writing %q: %v\n", pathMerged, err)
+		return mergeExitError
+	}
+	if conflicts > 0 {
+		fmt.Fprintf(os.Stderr, "%d conflicts in %s\n", conflicts, pathMerged)
+		return mergeExitConflicts
+	}
+	return mergeExitClean
+}
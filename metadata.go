@@ -6,6 +6,11 @@ const (
 	changeModified change = "modified"
 	changeAdded    change = "added"
 	changeDeleted  change = "deleted"
+	changeRenamed  change = "renamed"
+	changeCopied   change = "copied"
+
+	// changeUnchanged is only used for directory diffs
+	changeUnchanged change = "unchanged"
 )
 
 type Metadata struct {
@@ -17,4 +22,13 @@ type Metadata struct {
 	Hash      string `json:"hash"`
 	DirHash   string `json:"dirhash"`
 	Timestamp int64  `json:"timestamp"`
+
+	// Similarity is the similarity (in percent) of renamed and copied files.
+	Similarity int `json:"similarity,omitempty"`
+}
+
+// File is a diff to be displayed in the browser, along with its metadata.
+type File struct {
+	Metadata *Metadata `json:"metadata"`
+	Diff     string    `json:"diff"`
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/octavore/delta/lib"
+	"github.com/octavore/delta/lib/formatter"
+
+	"github.com/pkg/browser"
+)
+
+// ansiEscape matches terminal color codes, which git adds to its output
+// when color.ui is enabled.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// stdinIsPipe returns true if stdin is a pipe or a file, rather than a terminal.
+func stdinIsPipe() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice == 0
+}
+
+// runPager reads a patch, e.g. the output of git diff, from stdin and
+// displays it. This allows delta to be used as git's pager.
+func runPager() {
+	config := loadSettings()
+	if _, err := delta.ParseGranularity(*granularity); err != nil {
+		os.Stderr.WriteString(err.Error())
+		return
+	}
+	if *format != FormatOptionHTML && *output == OutputOptionCLI {
+		if err := colorPatch(os.Stdout, os.Stdin); err != nil {
+			os.Stderr.WriteString(err.Error())
+		}
+		return
+	}
+	in, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		os.Stderr.WriteString(err.Error())
+		return
+	}
+	patch := ansiEscape.ReplaceAllString(string(in), "")
+
+	switch *format {
+	case FormatOptionHTML:
+		files, err := delta.ParsePatch(strings.NewReader(patch))
+		if err != nil {
+			os.Stderr.WriteString(err.Error())
+			return
+		}
+		if len(files) == 0 {
+			os.Stderr.WriteString("no diffs found in input")
+			return
+		}
+		page, err := patchHTML(files, config)
+		if err != nil {
+			os.Stderr.WriteString(err.Error())
+			return
+		}
+		switch *output {
+		case OutputOptionCLI:
+			page.WriteTo(os.Stdout)
+		case OutputOptionGist:
+			uploadGist(page.Bytes())
+		case OutputOptionBrowser:
+			browser.OpenReader(page)
+		}
+
+	default:
+		switch *output {
+		case OutputOptionGist:
+			uploadGist([]byte(patch))
+		case OutputOptionBrowser:
+			browser.OpenReader(bytes.NewBufferString(patch))
+		}
+	}
+}
+
+// colorPatch colors the patch read from r line by line, so that e.g. the
+// output of git log -p is shown as git produces it, rather than when it
+// exits. The output is flushed whenever delta has to wait for more input.
+func colorPatch(w io.Writer, r io.Reader) error {
+	in := bufio.NewReader(r)
+	out := bufio.NewWriter(w)
+	c := formatter.NewPatchColorer(out, formatterOptions()...)
+	for {
+		l, err := in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if l != "" {
+			l = ansiEscape.ReplaceAllString(strings.TrimSuffix(l, "\n"), "")
+			if err := c.WriteLine(l); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			if err := c.Flush(); err != nil {
+				return err
+			}
+			return out.Flush()
+		}
+		if in.Buffered() == 0 {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// patchHTML renders all files in a patch into a single html page, with
+// intra-line differences for changed lines. Added and deleted files which
+// are similar are shown as renames.
+func patchHTML(files []*delta.FilePatch, config Config) (*bytes.Buffer, error) {
+	if *findRenames > 0 {
+		files = delta.DetectPatchRenames(files, delta.RenameOptions{
+			Threshold: *findRenames,
+			Context:   formatter.DefaultContext,
+		})
+	}
+	pageFiles := []*File{}
+	for _, f := range files {
+		change := changeModified
+		merged := f.NewName
+		switch f.Status {
+		case delta.FileAdded:
+			change = changeAdded
+		case delta.FileDeleted:
+			change = changeDeleted
+			merged = f.OldName
+		case delta.FileRenamed:
+			change = changeRenamed
+		case delta.FileCopied:
+			change = changeCopied
+		}
+		lines := []delta.Line{}
+		for _, h := range f.Hunks {
+			lines = append(lines, delta.PairChanges(h.Lines)...)
+		}
+		ctx, cancel := timeoutContext()
+		file := newFile(f.OldName, f.NewName, merged, change, formatter.HTMLLines(lines,
+			append(formatterOptions(), formatter.WithFileStatus(f.Status), formatter.WithContext(ctx))...))
+		cancel()
+		file.Metadata.Similarity = f.Similarity
+		pageFiles = append(pageFiles, file)
+	}
+	return page(pageFiles, config)
+}